@@ -0,0 +1,120 @@
+package gochecksumtype
+
+import (
+	"go/ast"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// condChain is a sequence of conditions testing the same subject against
+// different targets, such as `errors.As(err, &a)` and `errors.As(err, &b)`.
+type condChain struct {
+	node ast.Node
+	// subject is the source of the expression tested by the chain.
+	subject string
+	// targets are the expressions the subject is tested against.
+	targets []ast.Expr
+	// hasDefault is true if the chain ends in a non-panicking else branch or
+	// default clause.
+	hasDefault bool
+}
+
+// chainMatcher returns the source of the subject and the target tested by
+// cond if cond is of the form making up a chain.
+type chainMatcher func(cond ast.Expr) (subject string, target ast.Expr, ok bool)
+
+// condChains returns every chain of conditions accepted by match in pkg. A
+// chain is formed by
+//
+//   - an if statement and its else-if branches,
+//   - consecutive if statements, optionally separated by declarations of
+//     their targets, or
+//   - the cases of a switch statement without a tag,
+//
+// whose conditions test the same subject.
+func condChains(pkg *packages.Package, match chainMatcher) []condChain {
+	var chains []condChain
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				chains = append(chains, ifChains(n.List, match)...)
+			case *ast.CaseClause:
+				chains = append(chains, ifChains(n.Body, match)...)
+			case *ast.CommClause:
+				chains = append(chains, ifChains(n.Body, match)...)
+			case *ast.SwitchStmt:
+				if n.Tag == nil {
+					chains = append(chains, switchChain(n, match))
+				}
+			}
+			return true
+		})
+	}
+	return chains
+}
+
+// ifChains returns the chains formed by the if statements in stmts, each
+// including its else-if branches.
+func ifChains(stmts []ast.Stmt, match chainMatcher) []condChain {
+	var chains []condChain
+	var cur *condChain
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*ast.DeclStmt); ok && cur != nil {
+			continue
+		}
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			cur = nil
+			continue
+		}
+		subject, _, ok := match(ifStmt.Cond)
+		if !ok {
+			cur = nil
+			continue
+		}
+		if cur == nil || cur.subject != subject || cur.hasDefault {
+			chains = append(chains, condChain{node: ifStmt, subject: subject})
+			cur = &chains[len(chains)-1]
+		}
+		for ifStmt != nil {
+			s, target, ok := match(ifStmt.Cond)
+			if !ok || s != subject {
+				break
+			}
+			cur.targets = append(cur.targets, target)
+			switch els := ifStmt.Else.(type) {
+			case *ast.IfStmt:
+				ifStmt = els
+			case *ast.BlockStmt:
+				cur.hasDefault = !alwaysPanics(els.List)
+				ifStmt = nil
+			default:
+				ifStmt = nil
+			}
+		}
+	}
+	return chains
+}
+
+// switchChain returns the chain formed by the cases of a switch statement
+// without a tag that test the same subject as its first matching case.
+func switchChain(swtch *ast.SwitchStmt, match chainMatcher) condChain {
+	chain := condChain{node: swtch}
+	for _, stmt := range swtch.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		if clause.List == nil {
+			chain.hasDefault = !alwaysPanics(clause.Body)
+			continue
+		}
+		for _, expr := range clause.List {
+			subject, target, ok := match(expr)
+			if !ok || (chain.subject != "" && subject != chain.subject) {
+				continue
+			}
+			chain.subject = subject
+			chain.targets = append(chain.targets, target)
+		}
+	}
+	return chain
+}
@@ -3,6 +3,7 @@ package gochecksumtype
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"sort"
@@ -17,20 +18,41 @@ type inexhaustiveError struct {
 	Position token.Position
 	Def      sumTypeDef
 	Missing  []types.Object
+	// Excluded maps the variants ruled out by earlier checks, with
+	// Config.Narrow, to the positions of those checks.
+	Excluded map[types.Object]token.Position
 }
 
 func (e inexhaustiveError) Pos() token.Position { return e.Position }
 func (e inexhaustiveError) Error() string {
-	return fmt.Sprintf(
+	msg := fmt.Sprintf(
 		"%s: exhaustiveness check failed for sum type %q (from %s): missing cases for %s",
-		e.Pos(), e.Def.Decl.TypeName, e.Def.Decl.Pos, strings.Join(e.Names(), ", "))
+		e.Pos(), e.Def.Decl.TypeName, e.Def.Decl.Pos, strings.Join(e.groupedNames(), ", "))
+	if len(e.Excluded) == 0 {
+		return msg
+	}
+	excluded := make([]types.Object, 0, len(e.Excluded))
+	for v := range e.Excluded {
+		excluded = append(excluded, v)
+	}
+	sort.Slice(excluded, func(i, j int) bool { return excluded[i].Name() < excluded[j].Name() })
+	var reasons []string
+	for _, v := range excluded {
+		reasons = append(reasons, fmt.Sprintf("%s by check at %s", v.Name(), e.Excluded[v]))
+	}
+	return msg + " (excluded " + strings.Join(reasons, ", ") + ")"
 }
 
 // Names returns a sorted list of names corresponding to the missing variant
 // cases.
 func (e inexhaustiveError) Names() []string {
-	list := make([]string, 0, len(e.Missing))
-	for _, o := range e.Missing {
+	return sortedNames(e.Missing)
+}
+
+// sortedNames returns the sorted names of the given objects.
+func sortedNames(objs []types.Object) []string {
+	list := make([]string, 0, len(objs))
+	for _, o := range objs {
 		list = append(list, o.Name())
 	}
 	sort.Strings(list)
@@ -38,11 +60,24 @@ func (e inexhaustiveError) Names() []string {
 }
 
 // check does exhaustiveness checking for the given sum type definitions in the
-// given package. Every instance of inexhaustive case analysis is returned.
+// given package. Every instance of inexhaustive case analysis is returned,
+// in type switches, in expression switches over a discriminator method or
+// sentinel values, in chains of errors.As and errors.Is checks, and in chains
+// of tests of the fields of oneof structs.
 func check(pkg *packages.Package, defs []sumTypeDef, config Config) []error {
-	var errs []error
+	errs := checkErrorChains(pkg, defs, config)
+	errs = append(errs, checkOneofChains(pkg, defs, config)...)
 	for _, astfile := range pkg.Syntax {
 		ast.Inspect(astfile, func(n ast.Node) bool {
+			if swtch, ok := n.(*ast.SwitchStmt); ok {
+				if err := checkDiscriminatorSwitch(pkg, defs, swtch, config); err != nil {
+					errs = append(errs, err)
+				}
+				if err := checkSentinelSwitch(pkg, defs, swtch, config); err != nil {
+					errs = append(errs, err)
+				}
+				return true
+			}
 			swtch, ok := n.(*ast.TypeSwitchStmt)
 			if !ok {
 				return true
@@ -50,19 +85,69 @@ func check(pkg *packages.Package, defs []sumTypeDef, config Config) []error {
 			if err := checkSwitch(pkg, defs, swtch, config); err != nil {
 				errs = append(errs, err)
 			}
+			if config.StrictReceivers {
+				errs = append(errs, checkCaseForms(pkg, defs, swtch)...)
+			}
+			if config.ReportEmptyCases {
+				errs = append(errs, checkEmptyCases(pkg, astfile, defs, swtch)...)
+			}
+			if config.ReportRedundantDefault {
+				if err := checkRedundantDefault(pkg, defs, swtch, config); err != nil {
+					errs = append(errs, err)
+				}
+			}
 			return true
 		})
 	}
 	return errs
 }
 
+// redundantDefaultError is returned from check for each type switch over a
+// sum type that covers every variant, but also has a default clause that
+// does not panic.
+type redundantDefaultError struct {
+	Position token.Position
+	Def      sumTypeDef
+}
+
+func (e redundantDefaultError) Pos() token.Position { return e.Position }
+func (e redundantDefaultError) Error() string {
+	return fmt.Sprintf(
+		"%s: default clause is unreachable since all variants of sum type %q (from %s) are covered, "+
+			"and would silently handle new variants; make it panic instead",
+		e.Pos(), e.Def.Decl.TypeName, e.Def.Decl.Pos)
+}
+
+// checkRedundantDefault returns an error if the given type switch covers
+// every variant of a sum type and also has a non-panicking default clause.
+// Replacing the clause with a panicking one keeps exhaustiveness checks in
+// effect regardless of DefaultSignifiesExhaustive.
+func checkRedundantDefault(
+	pkg *packages.Package,
+	defs []sumTypeDef,
+	swtch *ast.TypeSwitchStmt,
+	config Config,
+) error {
+	clause := defaultClause(swtch.Body)
+	if clause == nil || alwaysPanics(clause.Body) {
+		return nil
+	}
+	config.DefaultSignifiesExhaustive = false
+	def, missing := missingVariantsInSwitch(pkg, defs, swtch, config)
+	if def == nil || len(missing) > 0 {
+		return nil
+	}
+	return redundantDefaultError{Position: pkg.Fset.Position(clause.Pos()), Def: *def}
+}
+
 // checkSwitch performs an exhaustiveness check on the given type switch
 // statement. If the type switch is used on a sum type and does not cover
 // all variants of that sum type, then an error is returned indicating which
 // variants were missed.
 //
 // Note that if the type switch contains a non-panicing default case, then
-// exhaustiveness checks are disabled.
+// exhaustiveness checks are disabled. With Config.Narrow, variants ruled out
+// before the switch need not be covered.
 func checkSwitch(
 	pkg *packages.Package,
 	defs []sumTypeDef,
@@ -70,16 +155,81 @@ func checkSwitch(
 	config Config,
 ) error {
 	def, missing := missingVariantsInSwitch(pkg, defs, swtch, config)
+	var excluded map[types.Object]token.Position
+	if len(missing) > 0 && config.Narrow {
+		missing, excluded = narrow(pkg, def, swtch, missing)
+	}
 	if len(missing) > 0 {
 		return inexhaustiveError{
 			Position: pkg.Fset.Position(swtch.Pos()),
 			Def:      *def,
 			Missing:  missing,
+			Excluded: excluded,
 		}
 	}
 	return nil
 }
 
+// checkDiscriminatorSwitch performs an exhaustiveness check on an expression
+// switch over a call of the discriminator method of a sum type, as in
+// `switch x.Kind() {...}`. A variant is covered if one of the case constants
+// equals the constant returned by its discriminator method.
+//
+// As with type switches, a non-panicking default case disables the check if
+// DefaultSignifiesExhaustive is set.
+func checkDiscriminatorSwitch(
+	pkg *packages.Package,
+	defs []sumTypeDef,
+	swtch *ast.SwitchStmt,
+	config Config,
+) error {
+	call, ok := swtch.Tag.(*ast.CallExpr)
+	if !ok || len(call.Args) != 0 {
+		return nil
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	ty := pkg.TypesInfo.TypeOf(sel.X)
+	if ty == nil {
+		return nil
+	}
+	def := findDef(defs, ty)
+	if def == nil || def.Discriminants == nil || def.Decl.Options["discriminator"] != sel.Sel.Name {
+		return nil
+	}
+	exprs, hasDefault := switchVariants(swtch.Body)
+	if config.DefaultSignifiesExhaustive && hasDefault && !defaultClauseAlwaysPanics(swtch.Body) {
+		return nil
+	}
+	var missing []types.Object
+	for _, v := range def.Variants {
+		value, ok := def.Discriminants[v]
+		if !ok {
+			continue
+		}
+		covered := false
+		for _, expr := range exprs {
+			if c := pkg.TypesInfo.Types[expr].Value; c != nil && constant.Compare(c, token.EQL, value) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return inexhaustiveError{
+		Position: pkg.Fset.Position(swtch.Pos()),
+		Def:      *def,
+		Missing:  missing,
+	}
+}
+
 // missingVariantsInSwitch returns a list of missing variants corresponding to
 // the given switch statement. The corresponding sum type definition is also
 // returned. (If no sum type definition could be found, then no exhaustiveness
@@ -91,7 +241,7 @@ func missingVariantsInSwitch(
 	config Config,
 ) (*sumTypeDef, []types.Object) {
 	asserted := findTypeAssertExpr(swtch)
-	ty := pkg.TypesInfo.TypeOf(asserted)
+	ty := assertedType(pkg, asserted)
 	if ty == nil {
 		panic(fmt.Sprintf("no type found for asserted expression: %v", asserted))
 	}
@@ -102,8 +252,8 @@ func missingVariantsInSwitch(
 		// nothing we can do to check it.
 		return nil, nil
 	}
-	variantExprs, hasDefault := switchVariants(swtch)
-	if config.DefaultSignifiesExhaustive && hasDefault && !defaultClauseAlwaysPanics(swtch) {
+	variantExprs, hasDefault := switchVariants(swtch.Body)
+	if config.DefaultSignifiesExhaustive && hasDefault && !defaultClauseAlwaysPanics(swtch.Body) {
 		// A catch-all case defeats all exhaustiveness checks.
 		return def, nil
 	}
@@ -111,13 +261,31 @@ func missingVariantsInSwitch(
 	for _, expr := range variantExprs {
 		variantTypes = append(variantTypes, pkg.TypesInfo.TypeOf(expr))
 	}
-	return def, def.missing(variantTypes, config.IncludeSharedInterfaces)
+	if config.StrictReceivers {
+		variantTypes = strictCaseTypes(def, variantTypes)
+	}
+	missing := def.missing(variantTypes, config.IncludeSharedInterfaces)
+	if _, ok := def.Decl.Options["nil"]; ok && !hasNilCase(variantTypes) {
+		missing = append(missing, types.Universe.Lookup("nil"))
+	}
+	return def, missing
+}
+
+// hasNilCase returns true if one of the given case types is that of nil.
+func hasNilCase(tys []types.Type) bool {
+	for _, ty := range tys {
+		if basic, ok := ty.(*types.Basic); ok && basic.Kind() == types.UntypedNil {
+			return true
+		}
+	}
+	return false
 }
 
-// switchVariants returns all case expressions found in a type switch. This
-// includes expressions from cases that have a list of expressions.
-func switchVariants(swtch *ast.TypeSwitchStmt) (exprs []ast.Expr, hasDefault bool) {
-	for _, stmt := range swtch.Body.List {
+// switchVariants returns all case expressions found in the body of a switch
+// statement. This includes expressions from cases that have a list of
+// expressions.
+func switchVariants(body *ast.BlockStmt) (exprs []ast.Expr, hasDefault bool) {
+	for _, stmt := range body.List {
 		clause := stmt.(*ast.CaseClause)
 		if clause.List == nil {
 			hasDefault = true
@@ -128,29 +296,39 @@ func switchVariants(swtch *ast.TypeSwitchStmt) (exprs []ast.Expr, hasDefault boo
 	return
 }
 
-// defaultClauseAlwaysPanics returns true if the given switch statement has a
-// default clause that always panics. Note that this is done on a best-effort
-// basis. While there will never be any false positives, there may be false
-// negatives.
+// defaultClauseAlwaysPanics returns true if the body of the given switch
+// statement has a default clause that always panics. Note that this is done
+// on a best-effort basis. While there will never be any false positives,
+// there may be false negatives.
 //
 // If the given switch statement has no default clause, then this function
 // panics.
-func defaultClauseAlwaysPanics(swtch *ast.TypeSwitchStmt) bool {
-	var clause *ast.CaseClause
-	for _, stmt := range swtch.Body.List {
-		c := stmt.(*ast.CaseClause)
-		if c.List == nil {
-			clause = c
-			break
-		}
-	}
+func defaultClauseAlwaysPanics(body *ast.BlockStmt) bool {
+	clause := defaultClause(body)
 	if clause == nil {
 		panic("switch statement has no default clause")
 	}
-	if len(clause.Body) != 1 {
+	return alwaysPanics(clause.Body)
+}
+
+// defaultClause returns the default clause in the body of a switch
+// statement, or nil if there is none.
+func defaultClause(body *ast.BlockStmt) *ast.CaseClause {
+	for _, stmt := range body.List {
+		if clause := stmt.(*ast.CaseClause); clause.List == nil {
+			return clause
+		}
+	}
+	return nil
+}
+
+// alwaysPanics returns true if the given statements consist of a single call
+// of panic.
+func alwaysPanics(stmts []ast.Stmt) bool {
+	if len(stmts) != 1 {
 		return false
 	}
-	exprStmt, ok := clause.Body[0].(*ast.ExprStmt)
+	exprStmt, ok := stmts[0].(*ast.ExprStmt)
 	if !ok {
 		return false
 	}
@@ -177,12 +355,29 @@ func findTypeAssertExpr(swtch *ast.TypeSwitchStmt) ast.Expr {
 	return expr.(*ast.TypeAssertExpr).X
 }
 
+// assertedType returns the type of the expression asserted by a type switch.
+// If the expression converts a value whose type is a type parameter to an
+// interface, as in `any(x)`, the constraint of the type parameter is returned
+// instead, so that switches in generic code constrained by a sum type are
+// checked against it.
+func assertedType(pkg *packages.Package, asserted ast.Expr) types.Type {
+	ty := pkg.TypesInfo.TypeOf(asserted)
+	call, ok := ast.Unparen(asserted).(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || !pkg.TypesInfo.Types[call.Fun].IsType() {
+		return ty
+	}
+	if tparam, ok := pkg.TypesInfo.TypeOf(call.Args[0]).(*types.TypeParam); ok {
+		return tparam.Constraint()
+	}
+	return ty
+}
+
 // findDef returns the sum type definition corresponding to the given type. If
 // no such sum type definition exists, then nil is returned.
 func findDef(defs []sumTypeDef, needle types.Type) *sumTypeDef {
 	for i := range defs {
 		def := &defs[i]
-		if types.Identical(needle.Underlying(), def.Ty) {
+		if def.hasTypes() && types.Identical(needle.Underlying(), def.Ty) {
 			return def
 		}
 	}
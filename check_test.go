@@ -199,6 +199,51 @@ func main() {}
 	assert.Equal(t, "T", errs[0].(unsealedError).Decl.TypeName)
 }
 
+// TestSealingLeak tests that exported types implementing a sum type through
+// an embedded field are reported with Config.CheckSealingLeaks, along with
+// exported functions returning them.
+func TestSealingLeak(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type Base struct { T }
+
+type Extended struct { *A }
+
+type hidden struct { T }
+
+func NewBase() *Base { return nil }
+
+func main() {
+	switch T(nil).(type) {
+	case *A, *Base, *Extended, *hidden:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{CheckSealingLeaks: true})
+	assert.Equal(t, 3, len(errs))
+	var leaks []string
+	for _, err := range errs {
+		lerr, ok := err.(sealingLeakError)
+		assert.True(t, ok, "error was not sealingLeakError: %T", err)
+		leak := lerr.Type.Name() + "/" + lerr.Embedded.Name()
+		if lerr.Func != nil {
+			leak = lerr.Func.Name() + ":" + leak
+		}
+		leaks = append(leaks, leak)
+	}
+	assert.Equal(t, []string{"Base/T", "Extended/A", "NewBase:Base/T"}, leaks)
+}
+
 // TestNotInterface tests that we report an error if one tries to declare a sum
 // type that doesn't correspond to an interface.
 func TestNotInterface(t *testing.T) {
@@ -295,6 +340,94 @@ func main() {
 	assert.Equal(t, 0, len(errs))
 }
 
+// TestTypeParamConstrainedBySumType tests that type switches over values
+// whose type is a type parameter constrained by a sum type are checked.
+func TestTypeParamConstrainedBySumType(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func Eval[X T](x X) {
+	switch any(x).(type) {
+	case *A:
+	}
+	switch v := interface{}(x).(type) {
+	case *A, *B:
+		_ = v
+	}
+}
+
+func Other[X any](x X) {
+	switch any(x).(type) {
+	case *A:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+}
+
+// TestRedundantDefault tests that non-panicking default clauses in switches
+// covering every variant are reported when requested, regardless of
+// DefaultSignifiesExhaustive.
+func TestRedundantDefault(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A, *B:
+	default:
+	}
+	switch T(nil).(type) {
+	case *A, *B:
+	default:
+		panic("unreachable")
+	}
+	switch T(nil).(type) {
+	case *A:
+	default:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	for _, exhaustive := range []bool{false, true} {
+		errs := Run(pkgs, Config{ReportRedundantDefault: true, DefaultSignifiesExhaustive: exhaustive})
+		var redundant []error
+		for _, err := range errs {
+			if _, ok := err.(redundantDefaultError); ok {
+				redundant = append(redundant, err)
+			}
+		}
+		assert.Equal(t, 1, len(redundant))
+		assert.Equal(t, 16, redundant[0].(redundantDefaultError).Pos().Line)
+	}
+
+	errs := Run(pkgs, Config{DefaultSignifiesExhaustive: true})
+	assert.Equal(t, 0, len(errs))
+}
+
 func missingNames(t *testing.T, err error) []string {
 	t.Helper()
 	ierr, ok := err.(inexhaustiveError)
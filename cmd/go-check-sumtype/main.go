@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	gochecksumtype "github.com/alecthomas/go-check-sumtype"
@@ -13,32 +14,256 @@ import (
 func main() {
 	log.SetFlags(0)
 
-	defaultSignifiesExhaustive := flag.Bool(
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "graph":
+			graph(os.Args[2:])
+			return
+		case "generate":
+			generate(os.Args[2:])
+			return
+		case "list":
+			list(os.Args[2:])
+			return
+		}
+	}
+
+	config := configFlags(flag.CommandLine)
+
+	flag.Parse()
+	if flag.NArg() < 1 {
+		log.Fatalf("Usage: sumtype <packages>\n" +
+			"       sumtype graph [-format=dot|mermaid] <packages>\n" +
+			"       sumtype generate [-type=<names>] [-output=<file>] [<package>]\n" +
+			"       sumtype list <packages>\n")
+	}
+	args := os.Args[flag.NFlag()+1:]
+
+	pkgs := load(args)
+	if errs := gochecksumtype.Run(pkgs, config()); len(errs) > 0 {
+		var list []string
+		for _, err := range errs {
+			list = append(list, err.Error())
+		}
+		log.Fatal(strings.Join(list, "\n"))
+	}
+}
+
+// configFlags registers the flags controlling which sum types are found and
+// how they are checked. The returned function builds the corresponding
+// configuration once fs has been parsed.
+func configFlags(fs *flag.FlagSet) func() gochecksumtype.Config {
+	defaultSignifiesExhaustive := fs.Bool(
 		"default-signifies-exhaustive",
 		true,
 		"Presence of \"default\" case in switch statements satisfies exhaustiveness, if all members are not listed.",
 	)
 
-	includeSharedInterfaces := flag.Bool(
+	includeSharedInterfaces := fs.Bool(
 		"include-shared-interfaces",
 		false,
 		"Include shared interfaces in the exhaustiviness check.",
 	)
 
-	flag.Parse()
-	if flag.NArg() < 1 {
-		log.Fatalf("Usage: sumtype <packages>\n")
+	protobuf := fs.Bool(
+		"protobuf",
+		false,
+		"Treat protoc-gen-go oneof interfaces as sum types, requiring a nil case.",
+	)
+
+	external := fs.String(
+		"external",
+		"",
+		"Comma-separated list of fully qualified interfaces to treat as sum types, e.g. \"go/ast.Expr\".",
+	)
+
+	infer := fs.Bool(
+		"infer",
+		false,
+		"Treat every sealed interface whose implementations are all in its own package as a sum type.",
+	)
+
+	inferMarkerOnly := fs.Bool(
+		"infer-marker-only",
+		false,
+		"With -infer, only infer interfaces whose single unexported method has empty implementations.",
+	)
+
+	directives := fs.String(
+		"directives",
+		"",
+		"Comma-separated list of directives declaring a sum type, e.g. \"sumtype:decl,go-sumtype:decl\" (default \"sumtype:decl\").",
+	)
+
+	strictReceivers := fs.Bool(
+		"strict-receivers",
+		false,
+		"Require cases to use the value or pointer form of each variant that implements the sum type.",
+	)
+
+	checkComparable := fs.Bool(
+		"check-comparable",
+		false,
+		"Report comparisons and map keys of sum types that have incomparable variants.",
+	)
+
+	narrow := fs.Bool(
+		"narrow",
+		false,
+		"Exclude variants ruled out by earlier type assertions from type switch checks.",
+	)
+
+	reportRedundantDefault := fs.Bool(
+		"redundant-default",
+		false,
+		"Report non-panicking default clauses in type switches that cover every variant.",
+	)
+
+	reportEmptyCases := fs.Bool(
+		"empty-cases",
+		false,
+		"Report variants only handled by empty case clauses not marked //sumtype:noop.",
+	)
+
+	checkGenerated := fs.Bool(
+		"check-generated",
+		false,
+		"Report files written by the generate subcommand that are out of date.",
+	)
+
+	checkRegistries := fs.Bool(
+		"check-registries",
+		false,
+		"Report //sumtype:registry declarations that do not register every variant.",
+	)
+
+	checkGob := fs.Bool(
+		"check-gob",
+		false,
+		"Report variants of sum types declared with the gob option that are not registered with encoding/gob.",
+	)
+
+	checkDirectives := fs.Bool(
+		"check-directives",
+		false,
+		"Report unknown directives in the sumtype namespace or that of -directives, e.g. //sumtype:dcel.",
+	)
+
+	checkSealingLeaks := fs.Bool(
+		"check-sealing-leaks",
+		false,
+		"Report exported types that implement a sum type by embedding, allowing other packages to implement it.",
+	)
+
+	checkOneofLiterals := fs.Bool(
+		"check-oneof-literals",
+		false,
+		"Report composite literals of //sumtype:oneof structs that do not set exactly one field.",
+	)
+
+	return func() gochecksumtype.Config {
+		var externalSumTypes []string
+		if *external != "" {
+			externalSumTypes = strings.Split(*external, ",")
+		}
+		var directivePrefixes []string
+		if *directives != "" {
+			directivePrefixes = strings.Split(*directives, ",")
+		}
+		return gochecksumtype.Config{
+			DefaultSignifiesExhaustive: *defaultSignifiesExhaustive,
+			IncludeSharedInterfaces:    *includeSharedInterfaces,
+			Protobuf:                   *protobuf,
+			ExternalSumTypes:           externalSumTypes,
+			Infer:                      *infer,
+			InferMarkerOnly:            *inferMarkerOnly,
+			DirectivePrefixes:          directivePrefixes,
+			StrictReceivers:            *strictReceivers,
+			CheckComparable:            *checkComparable,
+			Narrow:                     *narrow,
+			ReportRedundantDefault:     *reportRedundantDefault,
+			ReportEmptyCases:           *reportEmptyCases,
+			CheckGenerated:             *checkGenerated,
+			CheckRegistries:            *checkRegistries,
+			CheckGobRegistrations:      *checkGob,
+			CheckDirectives:            *checkDirectives,
+			CheckSealingLeaks:          *checkSealingLeaks,
+			CheckOneofLiterals:         *checkOneofLiterals,
+		}
 	}
-	args := os.Args[flag.NFlag()+1:]
+}
 
-	config := gochecksumtype.Config{
-		DefaultSignifiesExhaustive: *defaultSignifiesExhaustive,
-		IncludeSharedInterfaces:    *includeSharedInterfaces,
+// graph implements the "graph" subcommand, which prints a diagram of the sum
+// type hierarchies declared in the given packages.
+func graph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "Diagram syntax, either \"dot\" or \"mermaid\".")
+	config := configFlags(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		log.Fatalf("Usage: sumtype graph [-format=dot|mermaid] <packages>\n")
+	}
+	pkgs := load(fs.Args())
+	if err := gochecksumtype.WriteGraph(os.Stdout, pkgs, config(), gochecksumtype.GraphFormat(*format)); err != nil {
+		log.Fatal(err)
 	}
+}
+
+// list implements the "list" subcommand, which prints every sum type found in
+// the given packages, including inferred ones, along with its variants.
+func list(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	config := configFlags(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		log.Fatalf("Usage: sumtype list <packages>\n")
+	}
+	pkgs := load(fs.Args())
+	if err := gochecksumtype.List(os.Stdout, pkgs, config()); err != nil {
+		log.Fatal(err)
+	}
+}
 
+// generate implements the "generate" subcommand, which writes visitor and
+// match helpers for the sum types of a single package. Without arguments it
+// uses the package in the current directory, so it can be run from a
+// go:generate directive.
+func generate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	typeNames := fs.String("type", "", "Comma-separated list of sum types to generate code for (default all).")
+	output := fs.String("output", "sumtype_gen.go", "Name of the generated file, relative to the package directory.")
+	config := configFlags(fs)
+	_ = fs.Parse(args)
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	pkgs := load(patterns)
+	if len(pkgs) != 1 {
+		log.Fatalf("generate: expected exactly one package, found %d", len(pkgs))
+	}
+	pkg := pkgs[0]
+	if len(pkg.GoFiles) == 0 {
+		log.Fatalf("generate: package %s has no Go files", pkg.PkgPath)
+	}
+	var names []string
+	if *typeNames != "" {
+		names = strings.Split(*typeNames, ",")
+	}
+	src, err := gochecksumtype.Generate(pkg, config(), names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dest := filepath.Join(filepath.Dir(pkg.GoFiles[0]), *output)
+	if err := os.WriteFile(dest, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func load(args []string) []*packages.Package {
 	conf := &packages.Config{
 		Mode: packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedTypes | packages.NeedTypesSizes |
-			packages.NeedImports | packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
+			packages.NeedImports | packages.NeedDeps | packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
 		// Unfortunately, it appears including the test packages in
 		// this lint makes it difficult to do exhaustiveness checking.
 		// Namely, it appears that compiling the test version of a
@@ -55,11 +280,5 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	if errs := gochecksumtype.Run(pkgs, config); len(errs) > 0 {
-		var list []string
-		for _, err := range errs {
-			list = append(list, err.Error())
-		}
-		log.Fatal(strings.Join(list, "\n"))
-	}
+	return pkgs
 }
@@ -0,0 +1,113 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// incomparableError corresponds to a comparison of sum type values, or a map
+// keyed by a sum type, where some variants of the sum type are not
+// comparable. Comparing such a variant panics at runtime.
+type incomparableError struct {
+	Position token.Position
+	Def      sumTypeDef
+	// Use describes how the sum type is compared.
+	Use      string
+	Variants []types.Object
+}
+
+func (e incomparableError) Pos() token.Position { return e.Position }
+func (e incomparableError) Error() string {
+	fset := e.Def.Decl.Package.Fset
+	variants := make([]string, 0, len(e.Variants))
+	for _, v := range e.Variants {
+		variants = append(variants, fmt.Sprintf("%s (%s)", v.Name(), fset.Position(v.Pos())))
+	}
+	return fmt.Sprintf(
+		"%s: %s sum type %q (from %s) panics for incomparable variants %s",
+		e.Pos(), e.Use, e.Def.Decl.TypeName, e.Def.Decl.Pos, strings.Join(variants, ", "))
+}
+
+// checkComparisons reports every use of a sum type in pkg that compares its
+// values, namely == and != comparisons and expression switch cases other
+// than with nil, and map keys, if the sum type has variants that are not
+// comparable.
+func checkComparisons(pkg *packages.Package, defs []sumTypeDef) []error {
+	var errs []error
+	report := func(node ast.Node, ty types.Type, use string) {
+		if ty == nil {
+			return
+		}
+		def := findDef(defs, ty)
+		if def == nil {
+			return
+		}
+		if variants := incomparableVariants(def); len(variants) > 0 {
+			errs = append(errs, incomparableError{
+				Position: pkg.Fset.Position(node.Pos()),
+				Def:      *def,
+				Use:      use,
+				Variants: variants,
+			})
+		}
+	}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BinaryExpr:
+				if n.Op != token.EQL && n.Op != token.NEQ || isNil(pkg, n.X) || isNil(pkg, n.Y) {
+					return true
+				}
+				ty := pkg.TypesInfo.TypeOf(n.X)
+				if findDef(defs, ty) == nil {
+					ty = pkg.TypesInfo.TypeOf(n.Y)
+				}
+				report(n, ty, "comparing")
+			case *ast.SwitchStmt:
+				if n.Tag != nil && comparesValues(pkg, n) {
+					report(n, pkg.TypesInfo.TypeOf(n.Tag), "switching on")
+				}
+			case *ast.MapType:
+				report(n, pkg.TypesInfo.TypeOf(n.Key), "map keyed by")
+			}
+			return true
+		})
+	}
+	return errs
+}
+
+// incomparableVariants returns the concrete variants of def that can be
+// stored by value and whose value type is not comparable. Variants only
+// implementing the sum type through their pointer type are always
+// comparable.
+func incomparableVariants(def *sumTypeDef) []types.Object {
+	var variants []types.Object
+	for _, v := range def.Variants {
+		if def.ByValue[v] && !isInterface(v.Type()) && !types.Comparable(v.Type()) {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+// comparesValues returns true if the expression switch swtch has a case
+// expression other than nil, so that it compares the values switched on.
+func comparesValues(pkg *packages.Package, swtch *ast.SwitchStmt) bool {
+	exprs, _ := switchVariants(swtch.Body)
+	for _, expr := range exprs {
+		if !isNil(pkg, expr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isNil returns true if expr is the predeclared nil.
+func isNil(pkg *packages.Package, expr ast.Expr) bool {
+	return pkg.TypesInfo.Types[expr].IsNil()
+}
@@ -0,0 +1,58 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestIncomparableVariants tests that comparisons, expression switches and
+// map keys of sum types with incomparable variants are reported, except for
+// comparisons with nil.
+func TestIncomparableVariants(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct { Items []int }
+func (a A) sealed() {}
+
+type B struct { Items []int }
+func (b *B) sealed() {}
+
+type C struct { Name string }
+func (c C) sealed() {}
+
+func main() {
+	var x, y T
+	_ = x == y
+	_ = x != nil
+	switch x {
+	case y:
+	}
+	switch x {
+	case nil:
+	}
+	switch x {
+	case nil, y:
+	}
+	_ = map[T]int{}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{CheckComparable: true})
+	assert.Equal(t, 4, len(errs))
+	var uses []string
+	for _, err := range errs {
+		cerr, ok := err.(incomparableError)
+		assert.True(t, ok, "error was not incomparableError: %T", err)
+		assert.Equal(t, []string{"A"}, sortedNames(cerr.Variants))
+		uses = append(uses, cerr.Use)
+	}
+	assert.Equal(t, []string{"comparing", "switching on", "switching on", "map keyed by"}, uses)
+	assert.Contains(t, errs[0].Error(), "A (")
+}
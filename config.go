@@ -5,4 +5,67 @@ type Config struct {
 	// IncludeSharedInterfaces in the exhaustiviness check. If true, we do not need to list all concrete structs, as long
 	// as the switch statement is exhaustive with respect to interfaces the structs implement.
 	IncludeSharedInterfaces bool
+	// Protobuf treats the interfaces generated by protoc-gen-go for oneof
+	// fields as sum types whose type switches must also handle nil.
+	Protobuf bool
+	// ExternalSumTypes lists fully qualified interfaces, such as
+	// "go/ast.Expr", to treat as sum types without a declaration comment.
+	// Their packages must be among the loaded packages or their
+	// dependencies.
+	ExternalSumTypes []string
+	// Infer treats every undeclared sealed interface in the checked packages
+	// as a sum type, provided it has implementations and none of them are
+	// in other checked packages.
+	Infer bool
+	// InferMarkerOnly restricts Infer to interfaces whose only unexported
+	// method is implemented with an empty body by every variant.
+	InferMarkerOnly bool
+	// DirectivePrefixes lists the comment directives, without the leading
+	// "//", that declare a sum type. Defaults to "sumtype:decl". Other tools
+	// use spellings such as "go-sumtype:decl" or "exhaustive:sum".
+	DirectivePrefixes []string
+	// StrictReceivers requires type switch cases to use the form each
+	// variant is stored in the sum type with, *T for pointer receivers and,
+	// for value receivers, the only form T or *T converted to the sum type.
+	// Variants with value receivers stored in both forms are reported.
+	StrictReceivers bool
+	// CheckComparable reports comparisons of sum type values, and maps keyed
+	// by sum types, when some variants are not comparable.
+	CheckComparable bool
+	// Narrow excludes from type switches the variants ruled out on every
+	// path reaching the switch by earlier checks such as
+	// `if _, ok := x.(*A); ok { return }`.
+	Narrow bool
+	// ReportRedundantDefault reports type switches that cover every variant
+	// of a sum type but also have a default clause that does not panic, which
+	// would silently handle variants added later.
+	ReportRedundantDefault bool
+	// ReportEmptyCases reports variants of a sum type whose only case in a
+	// type switch has an empty body, unless the case is marked with a
+	// `//sumtype:noop` comment.
+	ReportEmptyCases bool
+	// CheckGenerated reports files written by Generate that are out of date
+	// with respect to the sum types they were generated for. Each such file
+	// is regenerated in memory and compared with its contents on disk.
+	CheckGenerated bool
+	// CheckRegistries reports declarations annotated with
+	// `//sumtype:registry` that do not register every variant of the named
+	// sum types.
+	CheckRegistries bool
+	// CheckGobRegistrations reports sum types declared with the `gob` option
+	// whose variants are not all registered with encoding/gob.
+	CheckGobRegistrations bool
+	// CheckDirectives reports comments in the "sumtype" namespace, or that
+	// of a directive in DirectivePrefixes, that are not known directives,
+	// such as `//sumtype:dcel`, and comments in misspellings of those
+	// namespaces, such as `//sumtpye:decl`.
+	CheckDirectives bool
+	// CheckSealingLeaks reports exported types of the package declaring a
+	// sum type that implement it through an embedded field, such as
+	// `struct{ T }`, and exported functions returning them, since other
+	// packages can embed them to implement the sum type.
+	CheckSealingLeaks bool
+	// CheckOneofLiterals reports composite literals of structs declared with
+	// `//sumtype:oneof` that do not set exactly one field.
+	CheckOneofLiterals bool
 }
@@ -1,13 +1,34 @@
 package gochecksumtype
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
+	"slices"
 	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
+// sumTypeKind is the kind of a sum type, which determines what its variants
+// are.
+type sumTypeKind int
+
+const (
+	// kindInterface is a sealed interface, whose variants are the types
+	// implementing it.
+	kindInterface sumTypeKind = iota
+	// kindUnion is a union constraint, whose variants are the types of its
+	// type set.
+	kindUnion
+	// kindValues is a var block, whose variants are the variables it
+	// declares.
+	kindValues
+	// kindOneof is a struct declared with `//sumtype:oneof`, whose variants
+	// are its fields.
+	kindOneof
+)
+
 // sumTypeDecl is a declaration of a sum type in a Go source file.
 type sumTypeDecl struct {
 	// The package path that contains this decl.
@@ -16,6 +37,20 @@ type sumTypeDecl struct {
 	TypeName string
 	// Position where the declaration was found.
 	Pos token.Position
+	// Options given after the directive, e.g. `//sumtype:decl json=kind`.
+	// Options without a value map to the empty string.
+	Options map[string]string
+	// Inferred is true if the sum type was inferred from a sealed interface
+	// rather than declared.
+	Inferred bool
+	// Kind is the kind of sum type declared. Declarations of types other
+	// than oneof structs are of kind kindInterface, even if the type turns
+	// out to be a union constraint.
+	Kind sumTypeKind
+	// Values are the names of the variables declared by a var block with a
+	// sum type declaration of kind kindValues, whose values are the variants
+	// of the sum type.
+	Values []string
 }
 
 // Location returns a short string describing where this declaration was found.
@@ -23,9 +58,21 @@ func (d sumTypeDecl) Location() string {
 	return d.Pos.String()
 }
 
+// defaultDeclDirectives are the directives declaring a sum type when
+// Config.DirectivePrefixes is empty.
+var defaultDeclDirectives = []string{"sumtype:decl"}
+
+// knownDirectives are the directives other than sum type declarations that
+// are understood by this package, used to detect misspellings.
+var knownDirectives = []string{"sumtype:registry", "sumtype:tag", "sumtype:oneof", "sumtype:noop"}
+
 // findSumTypeDecls searches every package given for sum type declarations of
-// the form `sumtype:decl`.
-func findSumTypeDecls(pkgs []*packages.Package) ([]sumTypeDecl, error) {
+// the form `sumtype:decl`, or any of the given alternative directives. The
+// directive may document a type, or a var block declaring sentinel values.
+func findSumTypeDecls(pkgs []*packages.Package, directives []string) ([]sumTypeDecl, error) {
+	if len(directives) == 0 {
+		directives = defaultDeclDirectives
+	}
 	var decls []sumTypeDecl
 	var retErr error
 	for _, pkg := range pkgs {
@@ -39,24 +86,37 @@ func findSumTypeDecls(pkgs []*packages.Package) ([]sumTypeDecl, error) {
 					return true
 				}
 				var tspec *ast.TypeSpec
+				var values []string
 				for _, spec := range decl.Specs {
-					ts, ok := spec.(*ast.TypeSpec)
-					if !ok {
-						continue
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						tspec = spec
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							if name.Name != "_" {
+								values = append(values, name.Name)
+							}
+						}
 					}
-					tspec = ts
 				}
 				for _, line := range decl.Doc.List {
-					if !strings.HasPrefix(line.Text, "//sumtype:decl") {
+					args, ok := anyDirectiveArgs(line.Text, directives)
+					if !ok {
 						continue
 					}
 					pos := pkg.Fset.Position(decl.Pos())
+					if decl.Tok == token.VAR && len(values) > 0 {
+						decl := newSentinelDecl(pkg, pos, values, parseOptions(args))
+						debugf("found sentinel sum type decl: %s.%s", decl.Package.PkgPath, decl.TypeName)
+						decls = append(decls, decl)
+						break
+					}
 					if tspec == nil {
 						retErr = notFoundError{Decl: sumTypeDecl{Package: pkg, Pos: pos}}
 						return false
 					}
 					pos = pkg.Fset.Position(tspec.Pos())
-					decl := sumTypeDecl{Package: pkg, TypeName: tspec.Name.Name, Pos: pos}
+					decl := sumTypeDecl{Package: pkg, TypeName: tspec.Name.Name, Pos: pos, Options: parseOptions(args)}
 					debugf("found sum type decl: %s.%s", decl.Package.PkgPath, decl.TypeName)
 					decls = append(decls, decl)
 					break
@@ -67,3 +127,247 @@ func findSumTypeDecls(pkgs []*packages.Package) ([]sumTypeDecl, error) {
 	}
 	return decls, retErr
 }
+
+// externalNotFoundError corresponds to an entry of Config.ExternalSumTypes
+// that does not name a type of the loaded packages or their dependencies.
+// Since it comes from the configuration rather than from source, it has no
+// position.
+type externalNotFoundError struct {
+	Name string
+}
+
+func (e externalNotFoundError) Pos() token.Position { return token.Position{} }
+func (e externalNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"external sum type %q is not defined, expected a fully qualified name such as \"go/ast.Expr\" "+
+			"of a type in the loaded packages or their dependencies", e.Name)
+}
+
+// findExternalDecls returns a sum type declaration for each of the given
+// fully qualified type names, e.g. "go/ast.Expr", looking them up in the
+// given packages and their dependencies. An error is returned for each name
+// that cannot be found.
+func findExternalDecls(pkgs []*packages.Package, names []string) ([]sumTypeDecl, []error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	byPath := map[string]*packages.Package{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types != nil {
+			byPath[pkg.PkgPath] = pkg
+		}
+	})
+	var decls []sumTypeDecl
+	var errs []error
+	for _, name := range names {
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			errs = append(errs, externalNotFoundError{Name: name})
+			continue
+		}
+		pkg := byPath[name[:i]]
+		if pkg == nil || pkg.Types.Scope().Lookup(name[i+1:]) == nil {
+			errs = append(errs, externalNotFoundError{Name: name})
+			continue
+		}
+		obj := pkg.Types.Scope().Lookup(name[i+1:])
+		decl := sumTypeDecl{Package: pkg, TypeName: obj.Name(), Pos: pkg.Fset.Position(obj.Pos())}
+		debugf("found external sum type: %s.%s", pkg.PkgPath, decl.TypeName)
+		decls = append(decls, decl)
+	}
+	return decls, errs
+}
+
+// directiveArgs reports whether the comment text is the directive
+// `//<name>`, and if so returns the whitespace separated arguments following
+// it. Whitespace between `//` and the directive name is ignored.
+func directiveArgs(text, name string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		return nil, false
+	}
+	rest, ok = strings.CutPrefix(strings.TrimLeft(rest, " \t"), name)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return nil, false
+	}
+	return strings.Fields(rest), true
+}
+
+// anyDirectiveArgs is like directiveArgs, but accepts any of the given
+// directive names.
+func anyDirectiveArgs(text string, names []string) ([]string, bool) {
+	for _, name := range names {
+		if args, ok := directiveArgs(text, name); ok {
+			return args, true
+		}
+	}
+	return nil, false
+}
+
+// misspeltDirectiveError corresponds to a comment using the namespace of a
+// directive understood by this package, such as `//sumtype:`, with a verb
+// that is not understood, which is most likely a typo.
+type misspeltDirectiveError struct {
+	Position token.Position
+	Text     string
+	// Suggestion is the known directive closest to Text, if any is close.
+	Suggestion string
+}
+
+func (e misspeltDirectiveError) Pos() token.Position { return e.Position }
+func (e misspeltDirectiveError) Error() string {
+	if e.Suggestion == "" {
+		return fmt.Sprintf("%s: %q is not a known directive", e.Pos(), e.Text)
+	}
+	return fmt.Sprintf("%s: %q looks like a misspelt directive, did you mean //%s?", e.Pos(), e.Text, e.Suggestion)
+}
+
+// checkDirectiveSpelling reports comments in pkg of the form `//ns:verb`,
+// where ns is "sumtype" or the namespace of one of the given declaration
+// directives, and verb is not understood by this package, such as
+// `//sumtype:dcel`, and comments whose ns is a misspelling of such a
+// namespace, such as `//sumtpye:decl`. Directives of other tools, such as
+// `//nolint:gochecksumtype`, are never reported.
+func checkDirectiveSpelling(pkg *packages.Package, declDirectives []string) []error {
+	if len(declDirectives) == 0 {
+		declDirectives = defaultDeclDirectives
+	}
+	known := append(slices.Clone(declDirectives), knownDirectives...)
+	var errs []error
+	for _, file := range pkg.Syntax {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				if suggestion, ok := misspeltDirective(c.Text, known); ok {
+					errs = append(errs, misspeltDirectiveError{
+						Position:   pkg.Fset.Position(c.Pos()),
+						Text:       c.Text,
+						Suggestion: suggestion,
+					})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// misspeltDirective reports whether the comment text is a directive in the
+// namespace of one of the known directives, or in a namespace within a small
+// edit distance of one such as "sumtpye", but not a known directive itself,
+// and if so returns the known directive it most likely meant.
+//
+// Other tools may define directives in the namespaces of configured
+// declaration directives, such as `//exhaustive:ignore` alongside
+// `//exhaustive:sum`, so outside the "sumtype" namespace only verbs within a
+// small edit distance of a known one are reported.
+func misspeltDirective(text string, known []string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, "//")
+	if !ok {
+		return "", false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", false
+	}
+	namespace, verb, ok := strings.Cut(fields[0], ":")
+	if !ok || verb == "" {
+		return "", false
+	}
+	best, bestDistance := "", -1
+	for _, directive := range known {
+		knownNamespace, knownVerb, _ := strings.Cut(directive, ":")
+		if knownNamespace != namespace {
+			continue
+		}
+		if knownVerb == verb {
+			return "", false
+		}
+		if d := editDistance(verb, knownVerb); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = directive, d
+		}
+	}
+	switch {
+	case bestDistance < 0:
+		return misspeltNamespace(fields[0], namespace, known)
+	case bestDistance <= 2:
+		return best, true
+	case namespace == "sumtype":
+		return "", true
+	default:
+		return "", false
+	}
+}
+
+// misspeltNamespace reports whether namespace, the namespace of the
+// directive, is within a small edit distance of the namespace of a known
+// directive, and if so returns the known directive closest to directive.
+func misspeltNamespace(directive, namespace string, known []string) (string, bool) {
+	best, bestDistance := "", -1
+	for _, candidate := range known {
+		knownNamespace, _, _ := strings.Cut(candidate, ":")
+		if editDistance(namespace, knownNamespace) > 2 {
+			continue
+		}
+		if d := editDistance(directive, candidate); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best, bestDistance >= 0
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// parseOptions parses directive arguments of the form `key` or `key=value`.
+func parseOptions(args []string) map[string]string {
+	if len(args) == 0 {
+		return nil
+	}
+	options := make(map[string]string, len(args))
+	for _, arg := range args {
+		key, value, _ := strings.Cut(arg, "=")
+		options[key] = value
+	}
+	return options
+}
+
+// typeDoc returns the comments documenting the type with the given name in
+// pkg, or nil if there are none.
+func typeDoc(pkg *packages.Package, name string) *ast.CommentGroup {
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			decl, ok := d.(*ast.GenDecl)
+			if !ok || decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				tspec := spec.(*ast.TypeSpec)
+				if tspec.Name.Name != name {
+					continue
+				}
+				if tspec.Doc != nil {
+					return tspec.Doc
+				}
+				return decl.Doc
+			}
+		}
+	}
+	return nil
+}
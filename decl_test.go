@@ -0,0 +1,152 @@
+package gochecksumtype
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestExternalSumType tests that interfaces from dependencies listed in the
+// configuration are checked like declared sum types.
+func TestExternalSumType(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "go/ast"
+
+func main() {
+	switch ast.Stmt(nil).(type) {
+	case *ast.BadStmt, *ast.DeclStmt, *ast.EmptyStmt, *ast.LabeledStmt, *ast.ExprStmt,
+		*ast.SendStmt, *ast.IncDecStmt, *ast.AssignStmt, *ast.GoStmt, *ast.DeferStmt,
+		*ast.ReturnStmt, *ast.BranchStmt, *ast.BlockStmt, *ast.IfStmt, *ast.CaseClause,
+		*ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.CommClause, *ast.SelectStmt, *ast.ForStmt:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{ExternalSumTypes: []string{"go/ast.Stmt"}})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"RangeStmt"}, missingNames(t, errs[0]))
+}
+
+// TestExternalSumTypeNotFound tests that unknown external sum types are
+// reported.
+func TestExternalSumTypeNotFound(t *testing.T) {
+	code := `
+package gochecksumtype
+
+func main() {}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{ExternalSumTypes: []string{"go/ast.Expr", "Expr"}})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "go/ast.Expr", errs[0].(externalNotFoundError).Name)
+	assert.Equal(t, "Expr", errs[1].(externalNotFoundError).Name)
+	assert.True(t, strings.HasPrefix(errs[0].Error(), "external sum type \"go/ast.Expr\""), errs[0].Error())
+}
+
+// TestDirectivePrefixes tests that alternative declaration directives, and
+// whitespace after the comment marker, are accepted when configured.
+func TestDirectivePrefixes(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//go-sumtype:decl
+type T interface { sealed() }
+
+// exhaustive:sum
+type U interface { sealedU() }
+
+type A struct {}
+func (a *A) sealed() {}
+func (a *A) sealedU() {}
+
+type B struct {}
+func (b *B) sealed() {}
+func (b *B) sealedU() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	}
+	switch U(nil).(type) {
+	case *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{DirectivePrefixes: []string{"go-sumtype:decl", "exhaustive:sum"}})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"A"}, missingNames(t, errs[1]))
+}
+
+// TestMisspeltDirective tests that unknown directives in the namespaces of
+// known ones, or in namespaces close to them, are reported with
+// Config.CheckDirectives, while directives of other tools are not.
+func TestMisspeltDirective(t *testing.T) {
+	tests := []struct {
+		name       string
+		comment    string
+		directives []string
+		// suggestion is the expected suggestion, or "-" if the comment is
+		// not reported.
+		suggestion string
+	}{
+		{name: "Typo", comment: "//sumtype:dcel", suggestion: "sumtype:decl"},
+		{name: "Whitespace", comment: "// sumtype:registyr T", suggestion: "sumtype:registry"},
+		{name: "UnknownVerb", comment: "//sumtype:exhaustive", suggestion: ""},
+		{name: "Known", comment: "//sumtype:decl", suggestion: "-"},
+		{name: "Nolint", comment: "//nolint:gochecksumtype", suggestion: "-"},
+		{name: "NolintReason", comment: "//nolint:gochecksumtype // generated", suggestion: "-"},
+		{name: "OtherNamespace", comment: "//go-sumtype:decl", suggestion: "-"},
+		{name: "Prose", comment: "// Sumtype checks do not apply to T.", suggestion: "-"},
+		{name: "NamespaceTransposed", comment: "//sumtpye:decl", suggestion: "sumtype:decl"},
+		{name: "NamespaceHyphen", comment: "//sum-type:registry T", suggestion: "sumtype:registry"},
+		{name: "NamespaceCase", comment: "//SumType:decl", suggestion: "sumtype:decl"},
+		{
+			name:       "ConfiguredTypo",
+			comment:    "//exhaustive:sun",
+			directives: []string{"exhaustive:sum"},
+			suggestion: "exhaustive:sum",
+		},
+		{
+			name:       "ConfiguredNamespaceTypo",
+			comment:    "//exhaustve:sum",
+			directives: []string{"exhaustive:sum"},
+			suggestion: "exhaustive:sum",
+		},
+		{
+			name:       "ConfiguredOtherTool",
+			comment:    "//exhaustive:ignore",
+			directives: []string{"exhaustive:sum"},
+			suggestion: "-",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := `
+package gochecksumtype
+
+` + test.comment + `
+type T interface { sealed() }
+`
+			pkgs := setupPackages(t, code)
+
+			assert.Equal(t, 0, len(Run(pkgs, Config{DirectivePrefixes: test.directives})))
+			errs := Run(pkgs, Config{DirectivePrefixes: test.directives, CheckDirectives: true})
+			if test.suggestion == "-" {
+				assert.Equal(t, 0, len(errs))
+				return
+			}
+			assert.Equal(t, 1, len(errs))
+			merr, ok := errs[0].(misspeltDirectiveError)
+			assert.True(t, ok, "error was not misspeltDirectiveError: %T", errs[0])
+			assert.Equal(t, test.suggestion, merr.Suggestion)
+		})
+	}
+}
@@ -3,9 +3,14 @@ package gochecksumtype
 import (
 	"flag"
 	"fmt"
+	"go/constant"
 	"go/token"
 	"go/types"
 	"log"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
 var debug = flag.Bool("debug", false, "enable debug logging")
@@ -36,6 +41,76 @@ func (e unsealedError) Error() string {
 		e.Decl.Location(), e.Decl.TypeName)
 }
 
+// markerNotFoundError corresponds to a declared sum type whose interface has
+// no unexported method and whose marker could not be identified, either
+// because the `marker` option names no marker type or because none of the
+// candidate markers is embedded by a loaded type.
+type markerNotFoundError struct {
+	Decl       sumTypeDecl
+	Candidates []types.Object
+}
+
+func (e markerNotFoundError) Pos() token.Position { return e.Decl.Pos }
+func (e markerNotFoundError) Error() string {
+	if name := e.Decl.Options["marker"]; name != "" {
+		return fmt.Sprintf(
+			"%s: marker %s of interface '%s' is not a struct without exported fields "+
+				"declaring every method of the interface",
+			e.Decl.Location(), name, e.Decl.TypeName)
+	}
+	names := make([]string, len(e.Candidates))
+	for i, obj := range e.Candidates {
+		names[i] = obj.Name()
+	}
+	return fmt.Sprintf(
+		"%s: interface '%s' is not sealed by an unexported method and no loaded type "+
+			"embeds its candidate markers %s (name the marker with marker=%s)",
+		e.Decl.Location(), e.Decl.TypeName, strings.Join(names, ", "), names[0])
+}
+
+// sealingLeakError corresponds to an exported type of the package declaring a
+// sum type that gets the sealing method of the sum type from an embedded
+// field. Other packages can embed such a type in their own types, which then
+// implement the sum type without being one of its variants. If Func is not
+// nil, the leaking type is reported through an exported function returning
+// it.
+type sealingLeakError struct {
+	Decl     sumTypeDecl
+	Position token.Position
+	Type     types.Object
+	Embedded types.Object
+	Func     *types.Func
+}
+
+func (e sealingLeakError) Pos() token.Position { return e.Position }
+func (e sealingLeakError) Error() string {
+	leak := fmt.Sprintf("exported type %s embeds %s", e.Type.Name(), e.Embedded.Name())
+	if e.Func != nil {
+		leak = fmt.Sprintf("exported function %s returns %s, which embeds %s", e.Func.Name(), e.Type.Name(), e.Embedded.Name())
+	}
+	return fmt.Sprintf(
+		"%s: %s, allowing other packages to implement sealed interface '%s' (from %s)",
+		e.Pos(), leak, e.Decl.TypeName, e.Decl.Location())
+}
+
+// markerBypassError corresponds to a type implementing a sum type sealed by
+// a marker without embedding the marker. Since every method of such a sum
+// type is exported, the type is not a variant, and values of it stored in
+// the sum type escape exhaustiveness checks.
+type markerBypassError struct {
+	Def      sumTypeDef
+	Position token.Position
+	Type     types.Object
+}
+
+func (e markerBypassError) Pos() token.Position { return e.Position }
+func (e markerBypassError) Error() string {
+	return fmt.Sprintf(
+		"%s: type %s implements sum type '%s' (from %s) without embedding its marker %s, "+
+			"embed the marker or seal the interface with an unexported method",
+		e.Pos(), e.Type.Name(), e.Def.Decl.TypeName, e.Def.Decl.Location(), e.Def.Markers[0].Name())
+}
+
 // notFoundError corresponds to a declared sum type whose type definition
 // could not be found in the same Go package.
 type notFoundError struct {
@@ -61,20 +136,50 @@ func (e notInterfaceError) Error() string {
 // sumTypeDef corresponds to the definition of a Go interface that is
 // interpreted as a sum type. Its variants are determined by finding all types
 // that implement said interface in the same package.
+//
+// If the interface is sealed by marker types, variants also include the
+// types of other loaded packages that embed a marker.
 type sumTypeDef struct {
-	Decl     sumTypeDecl
+	Decl sumTypeDecl
+	Kind sumTypeKind
+	// Ty is the interface of the sum type, or nil unless its variants are
+	// types, as reported by hasTypes.
 	Ty       *types.Interface
 	Variants []types.Object
+	Markers  []types.Object
+	// ByValue records the variants whose value type implements the
+	// interface. For all other variants, only the pointer type does.
+	ByValue map[types.Object]bool
+	// Discriminants maps each concrete variant to the constant returned by
+	// its discriminator method, if the declaration names one with the
+	// `discriminator` option.
+	Discriminants map[types.Object]constant.Value
+	// Stores records where the variants with value receivers are stored in
+	// the sum type by value and by pointer, as found by
+	// resolveReceiverForms with Config.StrictReceivers.
+	Stores map[types.Object]variantStores
+	// SubSums are the interface variants, as returned by subSums.
+	SubSums []types.Object
 }
 
 // findSumTypeDefs attempts to find a Go type definition for each of the given
 // sum type declarations. If no such sum type definition could be found for
-// any of the given declarations, then an error is returned.
-func findSumTypeDefs(decls []sumTypeDecl) ([]sumTypeDef, []error) {
+// any of the given declarations, then an error is returned. The loaded
+// packages are searched for variants of sum types sealed by marker types.
+func findSumTypeDefs(decls []sumTypeDecl, loaded []*types.Package) ([]sumTypeDef, []error) {
 	defs := make([]sumTypeDef, 0, len(decls))
 	var errs []error
 	for _, decl := range decls {
-		def, err := newSumTypeDef(decl.Package.Types, decl)
+		var def *sumTypeDef
+		var err error
+		switch decl.Kind {
+		case kindOneof:
+			def, err = newOneofStructDef(decl.Package.Types, decl)
+		case kindValues:
+			def = newSentinelDef(decl.Package.Types, decl)
+		default:
+			def, err = newSumTypeDef(decl.Package.Types, decl, loaded)
+		}
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -83,6 +188,7 @@ func findSumTypeDefs(decls []sumTypeDecl) ([]sumTypeDef, []error) {
 			errs = append(errs, notFoundError{decl})
 			continue
 		}
+		def.SubSums = subSums(def.Variants)
 		defs = append(defs, *def)
 	}
 	return defs, errs
@@ -93,8 +199,9 @@ func findSumTypeDefs(decls []sumTypeDecl) ([]sumTypeDef, []error) {
 // returns a nil def and a nil error.
 //
 // If the decl corresponds to a type that isn't an interface containing at
-// least one unexported method, then this returns an error.
-func newSumTypeDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
+// least one unexported method, or sealed by a marker type, then this returns
+// an error. Union constraints are handled by newUnionDef.
+func newSumTypeDef(pkg *types.Package, decl sumTypeDecl, loaded []*types.Package) (*sumTypeDef, error) {
 	obj := pkg.Scope().Lookup(decl.TypeName)
 	if obj == nil {
 		return nil, nil
@@ -103,6 +210,9 @@ func newSumTypeDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
 	if !ok {
 		return nil, notInterfaceError{decl}
 	}
+	if !iface.IsMethodSet() {
+		return newUnionDef(decl, iface)
+	}
 	hasUnexported := false
 	for i := range iface.NumMethods() {
 		if !iface.Method(i).Exported() {
@@ -110,17 +220,23 @@ func newSumTypeDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
 			break
 		}
 	}
-	if !hasUnexported {
+	markers, candidates := findMarkers(pkg, iface, decl.Options["marker"], loaded)
+	if !hasUnexported && len(markers) == 0 {
+		if decl.Options["marker"] != "" || len(candidates) > 0 {
+			return nil, markerNotFoundError{Decl: decl, Candidates: candidates}
+		}
 		return nil, unsealedError{decl}
 	}
 	def := &sumTypeDef{
-		Decl: decl,
-		Ty:   iface,
+		Decl:    decl,
+		Ty:      iface,
+		Markers: markers,
+		ByValue: map[types.Object]bool{},
 	}
 	debugf("searching for variants of %s.%s\n", pkg.Path(), decl.TypeName)
 	for _, name := range pkg.Scope().Names() {
 		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
-		if !ok {
+		if !ok || slices.Contains(markers, types.Object(obj)) {
 			continue
 		}
 		ty := obj.Type()
@@ -134,30 +250,193 @@ func newSumTypeDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
 		if types.Implements(ty, iface) || types.Implements(types.NewPointer(ty), iface) {
 			debugf("  found variant: %s.%s\n", pkg.Path(), obj.Name())
 			def.Variants = append(def.Variants, obj)
+			def.ByValue[obj] = types.Implements(obj.Type(), iface)
+		}
+	}
+	for _, other := range importers(markerPackages(markers), loaded) {
+		if other == pkg {
+			continue
+		}
+		for _, obj := range markerEmbedders(other, markers) {
+			if implements(obj.Type(), iface) {
+				debugf("  found variant embedding marker: %s.%s\n", other.Path(), obj.Name())
+				def.Variants = append(def.Variants, obj)
+				def.ByValue[obj] = types.Implements(obj.Type(), iface)
+			}
 		}
 	}
 	return def, nil
 }
 
+// findMarkers returns the marker types sealing iface, along with every
+// candidate marker it considered. A marker is a struct type without exported
+// fields, declared in pkg or in a package declaring one of the methods of
+// iface, that declares every method of iface itself and is meant to be
+// embedded by the variants, e.g.
+//
+//	type sealed struct{}
+//	func (sealed) isSum() {}
+//
+// If name is not empty, it selects the marker explicitly. Otherwise a
+// candidate is only recognised if it is embedded by a type of another loaded
+// package importing it, to distinguish it from an ordinary variant. If every
+// method of iface is exported, so that iface can only be sealed by a marker, a
+// candidate embedded by a type of its own package is recognised as well, so
+// that the declaring package can be checked on its own.
+func findMarkers(pkg *types.Package, iface *types.Interface, name string, loaded []*types.Package) (markers, candidates []types.Object) {
+	if iface.NumMethods() == 0 {
+		return nil, nil
+	}
+	exported := true
+	candidatePkgs := []*types.Package{pkg}
+	for i := range iface.NumMethods() {
+		m := iface.Method(i)
+		exported = exported && m.Exported()
+		if mpkg := m.Pkg(); mpkg != nil && !slices.Contains(candidatePkgs, mpkg) {
+			candidatePkgs = append(candidatePkgs, mpkg)
+		}
+	}
+	for _, cpkg := range candidatePkgs {
+		for _, n := range cpkg.Scope().Names() {
+			obj, ok := cpkg.Scope().Lookup(n).(*types.TypeName)
+			if !ok || obj.IsAlias() || !isMarker(obj.Type(), iface) {
+				continue
+			}
+			candidates = append(candidates, obj)
+			if name != "" {
+				if n == name {
+					markers = append(markers, obj)
+				}
+				continue
+			}
+			embedding := importers([]*types.Package{cpkg}, loaded)
+			if exported {
+				embedding = append(embedding, cpkg)
+			}
+			for _, other := range embedding {
+				if len(markerEmbedders(other, []types.Object{obj})) > 0 {
+					markers = append(markers, obj)
+					break
+				}
+			}
+		}
+	}
+	return markers, candidates
+}
+
+// markerPackages returns the packages declaring the given markers.
+func markerPackages(markers []types.Object) []*types.Package {
+	var pkgs []*types.Package
+	for _, marker := range markers {
+		if !slices.Contains(pkgs, marker.Pkg()) {
+			pkgs = append(pkgs, marker.Pkg())
+		}
+	}
+	return pkgs
+}
+
+// importers returns the packages among loaded that directly import one of
+// the given packages, which are the only ones able to embed types declared
+// in them.
+func importers(pkgs []*types.Package, loaded []*types.Package) []*types.Package {
+	var found []*types.Package
+	for _, other := range loaded {
+		if slices.ContainsFunc(other.Imports(), func(imp *types.Package) bool {
+			return slices.Contains(pkgs, imp)
+		}) {
+			found = append(found, other)
+		}
+	}
+	return found
+}
+
+// isMarker returns true if ty is a struct type without exported fields that
+// declares every method of iface itself, rather than through embedding.
+func isMarker(ty types.Type, iface *types.Interface) bool {
+	st, ok := ty.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range st.NumFields() {
+		if st.Field(i).Exported() {
+			return false
+		}
+	}
+	for i := range iface.NumMethods() {
+		m := iface.Method(i)
+		obj, index, _ := types.LookupFieldOrMethod(types.NewPointer(ty), true, m.Pkg(), m.Name())
+		if _, ok := obj.(*types.Func); !ok || len(index) != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// markerEmbedders returns the non-generic types of pkg that directly embed
+// one of the given markers.
+func markerEmbedders(pkg *types.Package, markers []types.Object) []types.Object {
+	if len(markers) == 0 {
+		return nil
+	}
+	var embedders []types.Object
+	for _, name := range pkg.Scope().Names() {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+		if named, ok := obj.Type().(*types.Named); ok && named.TypeParams() != nil {
+			continue
+		}
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := range st.NumFields() {
+			field := st.Field(i)
+			named, ok := indirect(field.Type()).(*types.Named)
+			if field.Embedded() && ok && slices.Contains(markers, types.Object(named.Obj())) {
+				embedders = append(embedders, obj)
+				break
+			}
+		}
+	}
+	return embedders
+}
+
 func (def *sumTypeDef) String() string {
 	return def.Decl.TypeName
 }
 
+// hasTypes returns true if the variants of this sum type are types, as for
+// interfaces and union constraints, rather than values or fields.
+func (def *sumTypeDef) hasTypes() bool {
+	return def.Kind == kindInterface || def.Kind == kindUnion
+}
+
 // missing returns a list of variants in this sum type that are not in the
-// given list of types.
+// given list of types. A type that is an interface variant of this sum type
+// covers the variants implementing it.
+//
+// Variants are compared by type, so this must only be used for sum types
+// whose variants are types, as reported by hasTypes.
 func (def *sumTypeDef) missing(tys []types.Type, includeSharedInterfaces bool) []types.Object {
 	// TODO(ag): This is O(n^2). Fix that. /shrug
 	var missing []types.Object
 	for _, v := range def.Variants {
 		found := false
-		varty := indirect(v.Type())
+		varty := v.Type()
+		if def.Kind != kindUnion {
+			varty = indirect(varty)
+		}
 		for _, ty := range tys {
-			ty = indirect(ty)
+			if def.Kind != kindUnion {
+				ty = indirect(ty)
+			}
 			if types.Identical(varty, ty) {
 				found = true
 				break
 			}
-			if includeSharedInterfaces && implements(varty, ty) {
+			if (includeSharedInterfaces || def.isSubSum(ty)) && implements(varty, ty) {
 				found = true
 				break
 			}
@@ -193,3 +472,117 @@ func implements(varty, interfaceType types.Type) bool {
 	}
 	return false
 }
+
+// findSealingLeaks returns an error for every exported type in the package
+// declaring def that implements it through an embedded field, rather than by
+// declaring the sealing method itself, and for every exported function
+// returning such a type.
+//
+// Sum types sealed by markers are expected to be implemented by embedding, so
+// they are not checked.
+func findSealingLeaks(def *sumTypeDef) []error {
+	if def.Kind != kindInterface {
+		return nil
+	}
+	sealer := sealingMethod(def.Ty)
+	if sealer == nil || len(def.Markers) > 0 {
+		return nil
+	}
+	pkg := def.Decl.Package
+	leaks := map[*types.TypeName]types.Object{}
+	var errs []error
+	for _, v := range def.Variants {
+		obj, ok := v.(*types.TypeName)
+		if !ok || !obj.Exported() || isInterface(obj.Type()) {
+			continue
+		}
+		embedded := embeddedSealer(obj.Type(), sealer)
+		if embedded == nil {
+			continue
+		}
+		leaks[obj] = embedded
+		errs = append(errs, sealingLeakError{
+			Decl:     def.Decl,
+			Position: pkg.Fset.Position(obj.Pos()),
+			Type:     obj,
+			Embedded: embedded,
+		})
+	}
+	if len(leaks) == 0 {
+		return errs
+	}
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		fn, ok := scope.Lookup(name).(*types.Func)
+		if !ok || !fn.Exported() {
+			continue
+		}
+		results := fn.Type().(*types.Signature).Results()
+		for i := range results.Len() {
+			named, ok := indirect(results.At(i).Type()).(*types.Named)
+			if !ok {
+				continue
+			}
+			if embedded, ok := leaks[named.Obj()]; ok {
+				errs = append(errs, sealingLeakError{
+					Decl:     def.Decl,
+					Position: pkg.Fset.Position(fn.Pos()),
+					Type:     named.Obj(),
+					Embedded: embedded,
+					Func:     fn,
+				})
+			}
+		}
+	}
+	return errs
+}
+
+// checkMarkerBypasses reports the types of pkg that implement a sum type
+// sealed only by markers, without an unexported method, but do not embed one
+// of its markers. Only the package declaring the sum type and packages
+// importing a marker are checked, since others cannot embed the markers.
+func checkMarkerBypasses(pkg *packages.Package, defs []sumTypeDef) []error {
+	var errs []error
+	for i := range defs {
+		def := &defs[i]
+		if len(def.Markers) == 0 || sealingMethod(def.Ty) != nil {
+			continue
+		}
+		if def.Decl.Package.PkgPath != pkg.PkgPath && len(importers(markerPackages(def.Markers), []*types.Package{pkg.Types})) == 0 {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() || isInterface(obj.Type()) {
+				continue
+			}
+			if slices.Contains(def.Variants, types.Object(obj)) || slices.Contains(def.Markers, types.Object(obj)) {
+				continue
+			}
+			if implements(obj.Type(), def.Ty) {
+				errs = append(errs, markerBypassError{Def: *def, Position: pkg.Fset.Position(obj.Pos()), Type: obj})
+			}
+		}
+	}
+	return errs
+}
+
+// embeddedSealer returns the embedded field of ty through which its pointer
+// method set gets the sealing method, or nil if ty declares the method
+// itself or does not have it.
+func embeddedSealer(ty types.Type, sealer *types.Func) types.Object {
+	obj, index, _ := types.LookupFieldOrMethod(types.NewPointer(ty), true, sealer.Pkg(), sealer.Name())
+	if _, ok := obj.(*types.Func); !ok || len(index) < 2 {
+		return nil
+	}
+	st, ok := ty.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	field := st.Field(index[0])
+	if named, ok := indirect(field.Type()).(*types.Named); ok {
+		return named.Obj()
+	}
+	return field
+}
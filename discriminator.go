@@ -0,0 +1,142 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// discriminatorError corresponds to a variant of a sum type whose
+// discriminator method does not return a single constant.
+type discriminatorError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Variant  types.Object
+	Reason   string
+}
+
+func (e discriminatorError) Pos() token.Position { return e.Position }
+func (e discriminatorError) Error() string {
+	return fmt.Sprintf(
+		"%s: discriminator %s of variant %s of sum type %q (from %s) %s",
+		e.Pos(), e.Def.Decl.Options["discriminator"], e.Variant.Name(), e.Def.Decl.TypeName, e.Def.Decl.Pos, e.Reason)
+}
+
+// duplicateDiscriminatorError corresponds to variants of a sum type whose
+// discriminator methods return the same constant.
+type duplicateDiscriminatorError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Value    constant.Value
+	Variants []types.Object
+}
+
+func (e duplicateDiscriminatorError) Pos() token.Position { return e.Position }
+func (e duplicateDiscriminatorError) Error() string {
+	names := make([]string, 0, len(e.Variants))
+	for _, v := range e.Variants {
+		names = append(names, v.Name())
+	}
+	return fmt.Sprintf(
+		"%s: variants %s of sum type %q (from %s) share the discriminator %s",
+		e.Pos(), strings.Join(names, ", "), e.Def.Decl.TypeName, e.Def.Decl.Pos, e.Value)
+}
+
+// resolveDiscriminators evaluates the discriminator method named by the
+// `discriminator` option of each sum type declaration, e.g.
+// `//sumtype:decl discriminator=Kind`, for every concrete variant, recording
+// the results in the definition's Discriminants.
+//
+// The method of each variant must consist of a single return statement
+// returning a constant, and no two variants may return the same constant.
+// Variants declared in packages whose source is not loaded, and sum types of
+// sentinel values and oneof structs, are skipped.
+func resolveDiscriminators(pkgs []*packages.Package, defs []sumTypeDef) []error {
+	var funcs map[*types.Func]*ast.FuncDecl
+	var infos map[*types.Func]*types.Info
+	var errs []error
+	for i := range defs {
+		def := &defs[i]
+		method, ok := def.Decl.Options["discriminator"]
+		if !ok || !def.hasTypes() {
+			continue
+		}
+		if funcs == nil {
+			funcs, infos = loadedFuncDecls(pkgs)
+		}
+		fset := def.Decl.Package.Fset
+		def.Discriminants = map[types.Object]constant.Value{}
+		var seen []types.Object
+		for _, v := range def.Variants {
+			if isInterface(v.Type()) {
+				continue
+			}
+			sel := types.NewMethodSet(types.NewPointer(v.Type())).Lookup(def.Decl.Package.Types, method)
+			if sel == nil {
+				errs = append(errs, discriminatorError{
+					Position: fset.Position(v.Pos()), Def: *def, Variant: v, Reason: "is not implemented",
+				})
+				continue
+			}
+			fn := sel.Obj().(*types.Func)
+			decl := funcs[fn]
+			if decl == nil {
+				continue
+			}
+			value := constantReturn(infos[fn], decl)
+			if value == nil {
+				errs = append(errs, discriminatorError{
+					Position: fset.Position(decl.Pos()), Def: *def, Variant: v, Reason: "does not return a single constant",
+				})
+				continue
+			}
+			for _, other := range seen {
+				if constant.Compare(def.Discriminants[other], token.EQL, value) {
+					errs = append(errs, duplicateDiscriminatorError{
+						Position: fset.Position(decl.Pos()), Def: *def, Value: value, Variants: []types.Object{other, v},
+					})
+					break
+				}
+			}
+			def.Discriminants[v] = value
+			seen = append(seen, v)
+		}
+	}
+	return errs
+}
+
+// loadedFuncDecls returns the declarations of the functions and methods with
+// bodies in the given packages and their dependencies whose source is loaded,
+// along with the type information of the package declaring each.
+func loadedFuncDecls(pkgs []*packages.Package) (map[*types.Func]*ast.FuncDecl, map[*types.Func]*types.Info) {
+	funcs := map[*types.Func]*ast.FuncDecl{}
+	infos := map[*types.Func]*types.Info{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.TypesInfo == nil {
+			return
+		}
+		for fn, decl := range funcDecls(pkg) {
+			funcs[fn] = decl
+			infos[fn] = pkg.TypesInfo
+		}
+	})
+	return funcs, infos
+}
+
+// constantReturn returns the constant returned by decl if its body is a
+// single return statement of a constant expression, or nil otherwise.
+func constantReturn(info *types.Info, decl *ast.FuncDecl) constant.Value {
+	if len(decl.Body.List) != 1 {
+		return nil
+	}
+	ret, ok := decl.Body.List[0].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return nil
+	}
+	return info.Types[ret.Results[0]].Value
+}
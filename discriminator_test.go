@@ -0,0 +1,119 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestDiscriminator tests that discriminator methods returning duplicate
+// values, non-constant values, or more than one value are reported.
+func TestDiscriminator(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl discriminator=Kind
+type T interface {
+	sealed()
+	Kind() string
+}
+
+const KindA = "a"
+
+type A struct {}
+func (a *A) sealed() {}
+func (a *A) Kind() string { return KindA }
+
+type B struct {}
+func (b *B) sealed() {}
+func (b *B) Kind() string { return "a" }
+
+type C struct { kind string }
+func (c *C) sealed() {}
+func (c *C) Kind() string { return c.kind }
+
+type D struct {}
+func (d D) sealed() {}
+func (d D) Kind() string { return "d" }
+
+type E struct { kind string }
+func (e *E) sealed() {}
+func (e *E) Kind() string {
+	if e.kind != "" {
+		return e.kind
+	}
+	return "e"
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 3, len(errs))
+	dup, ok := errs[0].(duplicateDiscriminatorError)
+	assert.True(t, ok, "error was not duplicateDiscriminatorError: %T", errs[0])
+	assert.Equal(t, []string{"A", "B"}, sortedNames(dup.Variants))
+	derr, ok := errs[1].(discriminatorError)
+	assert.True(t, ok, "error was not discriminatorError: %T", errs[1])
+	assert.Equal(t, "C", derr.Variant.Name())
+	derr, ok = errs[2].(discriminatorError)
+	assert.True(t, ok, "error was not discriminatorError: %T", errs[2])
+	assert.Equal(t, "E", derr.Variant.Name())
+}
+
+// TestDiscriminatorSwitch tests that expression switches over a
+// discriminator method are checked for missing variants.
+func TestDiscriminatorSwitch(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl discriminator=Kind
+type T interface {
+	sealed()
+	Kind() Kind
+}
+
+type Kind int
+
+const (
+	KindA Kind = iota
+	KindB
+	KindC
+)
+
+type A struct {}
+func (a *A) sealed() {}
+func (a *A) Kind() Kind { return KindA }
+
+type B struct {}
+func (b *B) sealed() {}
+func (b *B) Kind() Kind { return KindB }
+
+type C struct {}
+func (c C) sealed() {}
+func (c C) Kind() Kind { return KindC }
+
+func main() {
+	var x T
+	switch x.Kind() {
+	case KindA:
+	case KindB:
+	}
+	switch x.Kind() {
+	case KindA, KindB, KindC:
+	}
+	switch x.Kind() {
+	case KindA:
+	default:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, []string{"C"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"B", "C"}, missingNames(t, errs[1]))
+
+	errs = Run(pkgs, Config{DefaultSignifiesExhaustive: true})
+	assert.Equal(t, 1, len(errs))
+}
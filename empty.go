@@ -0,0 +1,110 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// emptyCaseError corresponds to a case clause of a type switch over a sum
+// type with an empty body, for variants not handled by any other clause.
+type emptyCaseError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Variants []types.Object
+}
+
+func (e emptyCaseError) Pos() token.Position { return e.Position }
+func (e emptyCaseError) Error() string {
+	return fmt.Sprintf(
+		"%s: variants %s of sum type %q (from %s) are only handled by an empty case, "+
+			"mark it //sumtype:noop if intentional",
+		e.Pos(), strings.Join(sortedNames(e.Variants), ", "), e.Def.Decl.TypeName, e.Def.Decl.Pos)
+}
+
+// checkEmptyCases reports every case clause of the given type switch over a
+// sum type whose body is empty, if it covers variants that no clause with a
+// non-empty body covers. Clauses containing a `//sumtype:noop` comment are
+// intentional no-ops and are not reported. Default clauses are not
+// considered.
+func checkEmptyCases(pkg *packages.Package, file *ast.File, defs []sumTypeDef, swtch *ast.TypeSwitchStmt) []error {
+	def := findDef(defs, assertedType(pkg, findTypeAssertExpr(swtch)))
+	if def == nil {
+		return nil
+	}
+	handled := map[types.Object]bool{}
+	for _, stmt := range swtch.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		if len(clause.Body) > 0 || isNoop(file, swtch, clause) {
+			for _, v := range coveredVariants(pkg, def, clause) {
+				handled[v] = true
+			}
+		}
+	}
+	var errs []error
+	for _, stmt := range swtch.Body.List {
+		clause := stmt.(*ast.CaseClause)
+		if clause.List == nil || len(clause.Body) > 0 || isNoop(file, swtch, clause) {
+			continue
+		}
+		var unhandled []types.Object
+		for _, v := range coveredVariants(pkg, def, clause) {
+			if !handled[v] {
+				unhandled = append(unhandled, v)
+			}
+		}
+		if len(unhandled) > 0 {
+			errs = append(errs, emptyCaseError{
+				Position: pkg.Fset.Position(clause.Pos()),
+				Def:      *def,
+				Variants: unhandled,
+			})
+		}
+	}
+	return errs
+}
+
+// coveredVariants returns the concrete variants of def covered by the types
+// listed by clause.
+func coveredVariants(pkg *packages.Package, def *sumTypeDef, clause *ast.CaseClause) []types.Object {
+	tys := make([]types.Type, 0, len(clause.List))
+	for _, expr := range clause.List {
+		tys = append(tys, pkg.TypesInfo.TypeOf(expr))
+	}
+	missing := def.missing(tys, false)
+	var covered []types.Object
+	for _, v := range def.Variants {
+		if !isInterface(v.Type()) && !slices.Contains(missing, v) {
+			covered = append(covered, v)
+		}
+	}
+	return covered
+}
+
+// isNoop returns true if clause of swtch contains a `//sumtype:noop`
+// comment, either after its colon or on its own line within the clause.
+func isNoop(file *ast.File, swtch *ast.TypeSwitchStmt, clause *ast.CaseClause) bool {
+	end := swtch.Body.Rbrace
+	for _, stmt := range swtch.Body.List {
+		if stmt.Pos() > clause.Pos() {
+			end = stmt.Pos()
+			break
+		}
+	}
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if c.Pos() < clause.Colon || c.Pos() >= end {
+				continue
+			}
+			if _, ok := directiveArgs(c.Text, "sumtype:noop"); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
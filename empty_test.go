@@ -0,0 +1,52 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestEmptyCases tests that variants only handled by empty case clauses are
+// reported, unless the clause is marked as an intentional no-op.
+func TestEmptyCases(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A, *B:
+	case *C:
+		println()
+	}
+	switch T(nil).(type) {
+	case *A: //sumtype:noop
+	case *B:
+		//sumtype:noop
+	case *C:
+		println()
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{ReportEmptyCases: true})
+	assert.Equal(t, 1, len(errs))
+	eerr, ok := errs[0].(emptyCaseError)
+	assert.True(t, ok, "error was not emptyCaseError: %T", errs[0])
+	assert.Equal(t, []string{"A", "B"}, sortedNames(eerr.Variants))
+
+	errs = Run(pkgs, Config{})
+	assert.Equal(t, 0, len(errs))
+}
@@ -0,0 +1,107 @@
+package gochecksumtype
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// checkErrorChains reports chains of errors.As checks in pkg that test for
+// some, but not all, variants of a sum type declared with the `errors`
+// option, e.g. `//sumtype:decl errors`. Chains are found as described by
+// condChains, whose conditions are calls of errors.As with the same error.
+// Chains of a single check are ignored, since matching one specific error is
+// common.
+//
+// Chains of errors.Is calls are likewise checked against sum types of
+// sentinel values by checkSentinelChain.
+func checkErrorChains(pkg *packages.Package, defs []sumTypeDef, config Config) []error {
+	var errs []error
+	report := func(chain condChain) {
+		if len(chain.targets) < 2 {
+			return
+		}
+		if config.DefaultSignifiesExhaustive && chain.hasDefault {
+			return
+		}
+		targets := make([]types.Type, 0, len(chain.targets))
+		for _, target := range chain.targets {
+			if ptr, ok := pkg.TypesInfo.TypeOf(target).(*types.Pointer); ok {
+				targets = append(targets, ptr.Elem())
+			}
+		}
+		if len(targets) == 0 {
+			return
+		}
+		def := errorSumTypeDef(defs, targets[0])
+		if def == nil {
+			return
+		}
+		if missing := def.missing(targets, config.IncludeSharedInterfaces); len(missing) > 0 {
+			errs = append(errs, inexhaustiveError{
+				Position: pkg.Fset.Position(chain.node.Pos()),
+				Def:      *def,
+				Missing:  missing,
+			})
+		}
+	}
+	for _, chain := range condChains(pkg, errorsMatcher(pkg, "As")) {
+		report(chain)
+	}
+	for _, chain := range condChains(pkg, errorsMatcher(pkg, "Is")) {
+		if err := checkSentinelChain(pkg, defs, chain, config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// errorsMatcher returns a chainMatcher for calls of the function with the
+// given name from the errors package, testing the error against a target.
+func errorsMatcher(pkg *packages.Package, name string) chainMatcher {
+	return func(cond ast.Expr) (string, ast.Expr, bool) {
+		return errorsCall(pkg, cond, name)
+	}
+}
+
+// errorsCall returns the source of the error expression and the target of
+// expr if it is a call of the function with the given name from the errors
+// package, as in `errors.As(err, &e)` or `errors.Is(err, ErrA)`.
+func errorsCall(pkg *packages.Package, expr ast.Expr, name string) (string, ast.Expr, bool) {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return "", nil, false
+	}
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	case *ast.Ident:
+		ident = fun
+	default:
+		return "", nil, false
+	}
+	fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "errors" || fn.Name() != name {
+		return "", nil, false
+	}
+	return types.ExprString(call.Args[0]), call.Args[1], true
+}
+
+// errorSumTypeDef returns the sum type declared with the `errors` option that
+// has a variant identical to ty, ignoring pointers.
+func errorSumTypeDef(defs []sumTypeDef, ty types.Type) *sumTypeDef {
+	for i := range defs {
+		def := &defs[i]
+		if _, ok := def.Decl.Options["errors"]; !ok || !def.hasTypes() {
+			continue
+		}
+		for _, v := range def.Variants {
+			if types.Identical(indirect(v.Type()), indirect(ty)) {
+				return def
+			}
+		}
+	}
+	return nil
+}
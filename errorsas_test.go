@@ -0,0 +1,105 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestErrorsAsChains tests that chains of errors.As checks against a sum
+// type declared with the errors option are checked for missing variants.
+func TestErrorsAsChains(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "errors"
+
+//sumtype:decl errors
+type Error interface {
+	error
+	sealed()
+}
+
+type NotFound struct {}
+func (e *NotFound) Error() string { return "not found" }
+func (e *NotFound) sealed() {}
+
+type Conflict struct {}
+func (e *Conflict) Error() string { return "conflict" }
+func (e *Conflict) sealed() {}
+
+type Internal struct {}
+func (e *Internal) Error() string { return "internal" }
+func (e *Internal) sealed() {}
+
+func elseIf(err error) {
+	var nf *NotFound
+	var c *Conflict
+	if errors.As(err, &nf) {
+	} else if errors.As(err, &c) {
+	}
+}
+
+func consecutive(err error) {
+	var nf *NotFound
+	if errors.As(err, &nf) {
+		return
+	}
+	var c *Conflict
+	if errors.As(err, &c) {
+		return
+	}
+	var i *Internal
+	if errors.As(err, &i) {
+		return
+	}
+}
+
+func consecutiveMissing(err error) {
+	var nf *NotFound
+	if errors.As(err, &nf) {
+		return
+	}
+	var i *Internal
+	if errors.As(err, &i) {
+		return
+	}
+}
+
+func tagless(err error) {
+	var nf *NotFound
+	var i *Internal
+	switch {
+	case errors.As(err, &nf):
+	case errors.As(err, &i):
+	}
+}
+
+func withDefault(err error) {
+	var nf *NotFound
+	var c *Conflict
+	if errors.As(err, &nf) {
+	} else if errors.As(err, &c) {
+	} else {
+		println(err)
+	}
+}
+
+func single(err error) {
+	var nf *NotFound
+	if errors.As(err, &nf) {
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 4, len(errs))
+	assert.Equal(t, []string{"Internal"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"Conflict"}, missingNames(t, errs[1]))
+	assert.Equal(t, []string{"Conflict"}, missingNames(t, errs[2]))
+	assert.Equal(t, []string{"Internal"}, missingNames(t, errs[3]))
+
+	errs = Run(pkgs, Config{DefaultSignifiesExhaustive: true})
+	assert.Equal(t, 3, len(errs))
+}
@@ -0,0 +1,365 @@
+package gochecksumtype
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// generatedHeader marks files written by Generate.
+const generatedHeader = "// Code generated by go-check-sumtype; DO NOT EDIT."
+
+// defaultJSONKey is the discriminator field used by generated JSON code when
+// the `json` option of a declaration has no value.
+const defaultJSONKey = "type"
+
+// staleGeneratedError corresponds to a file written by Generate whose
+// contents no longer match what Generate would produce, typically because
+// variants were added or removed since it was generated.
+type staleGeneratedError struct {
+	Position token.Position
+	File     string
+}
+
+func (e staleGeneratedError) Pos() token.Position { return e.Position }
+func (e staleGeneratedError) Error() string {
+	return fmt.Sprintf("%s: generated file %s is out of date, regenerate it with go-check-sumtype generate", e.Pos(), e.File)
+}
+
+// generatedFileError corresponds to a file written by Generate that could not
+// be compared with what Generate would produce, because reading the file or
+// generating its code failed.
+type generatedFileError struct {
+	Position token.Position
+	File     string
+	Err      error
+}
+
+func (e generatedFileError) Pos() token.Position { return e.Position }
+func (e generatedFileError) Error() string {
+	return fmt.Sprintf("%s: cannot check generated file %s: %v", e.Pos(), e.File, e.Err)
+}
+func (e generatedFileError) Unwrap() error { return e.Err }
+
+// Generate returns Go source for pkg containing, for each sum type declared
+// in it, a visitor interface with one method per variant, a Visit function
+// dispatching to that interface and a generic Match function taking one
+// function per variant.
+//
+// Sum types declared with the `json` option additionally get functions and a
+// wrapper type marshalling them as JSON objects tagged with their variant.
+//
+// Sum types are found as by Run with the given config, so that for example
+// Config.DirectivePrefixes applies. If typeNames is not empty, only the named sum types are generated, and
+// naming a sum type that is not an interface is an error. Otherwise such sum
+// types are skipped.
+func Generate(pkg *packages.Package, config Config, typeNames []string) ([]byte, error) {
+	defs, errs := sumTypes([]*packages.Package{pkg}, config)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	data := genFile{Header: generatedHeader, Package: pkg.Name}
+	for i := range defs {
+		def := &defs[i]
+		if len(typeNames) > 0 && !slices.Contains(typeNames, def.Decl.TypeName) {
+			continue
+		}
+		// Only interfaces have variant types to switch on, so other sum
+		// types are only generated, and rejected, when named explicitly.
+		if len(typeNames) == 0 && def.Kind != kindInterface {
+			continue
+		}
+		sum, err := newGenSumType(pkg, def)
+		if err != nil {
+			return nil, err
+		}
+		data.JSON = data.JSON || sum.JSON
+		data.SumTypes = append(data.SumTypes, sum)
+	}
+	if len(data.SumTypes) == 0 {
+		return nil, fmt.Errorf("%s: no sum types to generate", pkg.PkgPath)
+	}
+	var buf bytes.Buffer
+	if err := genTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+type genFile struct {
+	Header   string
+	Package  string
+	JSON     bool
+	SumTypes []genSumType
+}
+
+type genSumType struct {
+	Name     string
+	Variants []genVariant
+	// JSON is true if JSON marshalling code should be generated.
+	JSON bool
+	// JSONKey is the name of the discriminator field.
+	JSONKey string
+}
+
+type genVariant struct {
+	// Name of the variant type.
+	Name string
+	// Type is the form of the variant stored in the sum type, either Name or
+	// *Name.
+	Type string
+	// ByValue is true if Type is Name, in which case *Name implements the sum
+	// type too and generated switches also handle it.
+	ByValue bool
+	// Tag identifies the variant in JSON, set by a `//sumtype:tag` directive
+	// on the variant and defaulting to its name.
+	Tag string
+}
+
+// newGenSumType collects the concrete variants of def. Variants are referred
+// to by value when the value type implements the sum type, and by pointer
+// otherwise. As in exhaustiveness checks, a variant referred to by value may
+// also be stored as a pointer, which generated code dereferences.
+func newGenSumType(pkg *packages.Package, def *sumTypeDef) (genSumType, error) {
+	gen := genSumType{Name: def.Decl.TypeName}
+	switch def.Kind {
+	case kindUnion:
+		return gen, fmt.Errorf("%s: cannot generate code for union constraint %s", def.Decl.Pos, def.Decl.TypeName)
+	case kindValues, kindOneof:
+		return gen, fmt.Errorf("%s: cannot generate code for %s, which is not an interface", def.Decl.Pos, def.Decl.TypeName)
+	}
+	if key, ok := def.Decl.Options["json"]; ok {
+		if key == "" {
+			key = defaultJSONKey
+		}
+		if strings.ContainsAny(key, "\"`,\\") {
+			return gen, fmt.Errorf("%s: invalid JSON discriminator key %q", def.Decl.Pos, key)
+		}
+		gen.JSON = true
+		gen.JSONKey = key
+	}
+	tags := map[string]string{}
+	for _, v := range def.Variants {
+		if isInterface(v.Type()) {
+			continue
+		}
+		if v.Pkg() != pkg.Types {
+			return gen, fmt.Errorf("%s: cannot generate code for %s, variant %s is declared in package %s",
+				def.Decl.Pos, def.Decl.TypeName, v.Name(), v.Pkg().Path())
+		}
+		ty := "*" + v.Name()
+		if def.ByValue[v] {
+			ty = v.Name()
+		}
+		tag := variantTag(pkg, v)
+		if other, ok := tags[tag]; ok && gen.JSON {
+			return gen, fmt.Errorf("%s: variants %s and %s of %s share the JSON tag %q",
+				pkg.Fset.Position(v.Pos()), other, v.Name(), def.Decl.TypeName, tag)
+		}
+		tags[tag] = v.Name()
+		gen.Variants = append(gen.Variants, genVariant{Name: v.Name(), Type: ty, ByValue: def.ByValue[v], Tag: tag})
+	}
+	return gen, nil
+}
+
+// variantTag returns the JSON tag of a variant.
+func variantTag(pkg *packages.Package, v types.Object) string {
+	if doc := typeDoc(pkg, v.Name()); doc != nil {
+		for _, line := range doc.List {
+			if args, ok := directiveArgs(line.Text, "sumtype:tag"); ok && len(args) == 1 {
+				return args[0]
+			}
+		}
+	}
+	return v.Name()
+}
+
+// checkGenerated reports files in pkg written by Generate whose contents
+// differ from what Generate produces for the same sum types today. A file
+// that cannot be read or regenerated is reported along with the cause. Sum
+// types are found with the given config, as when generating the files.
+func checkGenerated(pkg *packages.Package, config Config) []error {
+	var errs []error
+	for _, file := range pkg.Syntax {
+		if len(file.Comments) == 0 || file.Comments[0].List[0].Text != generatedHeader {
+			continue
+		}
+		filename := pkg.Fset.File(file.Pos()).Name()
+		pos := pkg.Fset.Position(file.Pos())
+		current, err := os.ReadFile(filename)
+		if err != nil {
+			errs = append(errs, generatedFileError{Position: pos, File: filename, Err: err})
+			continue
+		}
+		src, err := Generate(pkg, config, generatedSumTypes(file))
+		if err != nil {
+			errs = append(errs, generatedFileError{Position: pos, File: filename, Err: err})
+			continue
+		}
+		if !bytes.Equal(src, current) {
+			errs = append(errs, staleGeneratedError{Position: pos, File: filename})
+		}
+	}
+	return errs
+}
+
+// generatedSumTypes returns the names of the sum types that a file written
+// by Generate contains code for, identified by their Visit functions.
+func generatedSumTypes(file *ast.File) []string {
+	var names []string
+	for _, d := range file.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || len(fn.Type.Params.List) == 0 {
+			continue
+		}
+		name, ok := strings.CutPrefix(fn.Name.Name, "Visit")
+		if !ok {
+			continue
+		}
+		if ident, ok := fn.Type.Params.List[0].Type.(*ast.Ident); ok && ident.Name == name {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+var genTemplate = template.Must(template.New("generate").Parse(`{{.Header}}
+
+package {{.Package}}
+
+import (
+{{- if .JSON}}
+	"encoding/json"
+{{- end}}
+	"fmt"
+)
+{{range .SumTypes}}{{$sum := .Name}}
+// {{$sum}}Visitor handles each variant of {{$sum}}.
+type {{$sum}}Visitor interface {
+{{- range .Variants}}
+	Visit{{.Name}}(v {{.Type}})
+{{- end}}
+}
+
+// Visit{{$sum}} calls the method of visitor corresponding to the variant held by v.
+func Visit{{$sum}}(v {{$sum}}, visitor {{$sum}}Visitor) {
+	switch v := v.(type) {
+{{- range .Variants}}
+	case {{.Type}}:
+		visitor.Visit{{.Name}}(v)
+{{- if .ByValue}}
+	case *{{.Name}}:
+		visitor.Visit{{.Name}}(*v)
+{{- end}}
+{{- end}}
+	default:
+		panic(fmt.Sprintf("unknown variant of {{$sum}}: %T", v))
+	}
+}
+
+// Match{{$sum}} calls the function corresponding to the variant held by v and
+// returns its result.
+func Match{{$sum}}[R any](v {{$sum}}{{range .Variants}}, on{{.Name}} func({{.Type}}) R{{end}}) R {
+	switch v := v.(type) {
+{{- range .Variants}}
+	case {{.Type}}:
+		return on{{.Name}}(v)
+{{- if .ByValue}}
+	case *{{.Name}}:
+		return on{{.Name}}(*v)
+{{- end}}
+{{- end}}
+	default:
+		panic(fmt.Sprintf("unknown variant of {{$sum}}: %T", v))
+	}
+}
+{{- if .JSON}}
+
+// Marshal{{$sum}}JSON encodes v as a JSON object whose {{printf "%q" .JSONKey}} field
+// identifies the variant held by v.
+func Marshal{{$sum}}JSON(v {{$sum}}) ([]byte, error) {
+	var tag string
+	switch v.(type) {
+{{- range .Variants}}
+	case {{.Type}}{{if .ByValue}}, *{{.Name}}{{end}}:
+		tag = {{printf "%q" .Tag}}
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unknown variant of {{$sum}}: %T", v)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("variant %T of {{$sum}} must encode as a JSON object: %w", v, err)
+	}
+	if fields == nil {
+		fields = map[string]json.RawMessage{}
+	}
+	fields[{{printf "%q" .JSONKey}}], err = json.Marshal(tag)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// Unmarshal{{$sum}}JSON decodes a JSON object written by Marshal{{$sum}}JSON into the
+// variant named by its {{printf "%q" .JSONKey}} field.
+func Unmarshal{{$sum}}JSON(data []byte) ({{$sum}}, error) {
+	var envelope struct {
+		Tag string ` + "`" + `json:"{{.JSONKey}}"` + "`" + `
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	switch envelope.Tag {
+{{- range .Variants}}
+	case {{printf "%q" .Tag}}:
+		var v {{.Name}}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return {{if .ByValue}}v{{else}}&v{{end}}, nil
+{{- end}}
+	default:
+		return nil, fmt.Errorf("unknown {{$sum}} variant %q", envelope.Tag)
+	}
+}
+
+// {{$sum}}JSON wraps a {{$sum}} so that it can be used as a field of types encoded
+// with encoding/json.
+type {{$sum}}JSON struct {
+	Value {{$sum}}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (j {{$sum}}JSON) MarshalJSON() ([]byte, error) {
+	return Marshal{{$sum}}JSON(j.Value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (j *{{$sum}}JSON) UnmarshalJSON(data []byte) error {
+	v, err := Unmarshal{{$sum}}JSON(data)
+	if err != nil {
+		return err
+	}
+	j.Value = v
+	return nil
+}
+{{- end}}
+{{end}}`))
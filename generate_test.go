@@ -0,0 +1,314 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+	"golang.org/x/tools/go/packages"
+)
+
+// TestGenerate tests that the generated visitor and match helpers cover every
+// concrete variant and compile against the original package.
+func TestGenerate(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b B) sealed() {}
+`
+	pkgs := setupPackages(t, code)
+
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+	out := string(src)
+	assert.Contains(t, out, generatedHeader)
+	assert.Contains(t, out, "type TVisitor interface {\n\tVisitA(v *A)\n\tVisitB(v B)\n}")
+	assert.Contains(t, out, "func VisitT(v T, visitor TVisitor) {")
+	assert.Contains(t, out, "func MatchT[R any](v T, onA func(*A) R, onB func(B) R) R {")
+	assert.Contains(t, out, "case B:\n\t\tvisitor.VisitB(v)\n\tcase *B:\n\t\tvisitor.VisitB(*v)")
+	assert.Contains(t, out, "case *B:\n\t\treturn onB(*v)")
+
+	pkgs = loadGenerated(t, code, src)
+	assert.Equal(t, 0, len(pkgs[0].Errors))
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+}
+
+// TestGenerateUnknownType tests that asking for an undeclared sum type fails.
+func TestGenerateUnknownType(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+`
+	pkgs := setupPackages(t, code)
+
+	_, err := Generate(pkgs[0], Config{}, []string{"U"})
+	assert.Error(t, err)
+}
+
+// TestGenerateSkipsUnions tests that union constraints are skipped unless
+// named explicitly, in which case generating code for them fails.
+func TestGenerateSkipsUnions(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+//sumtype:decl
+type Number interface { int64 | float64 }
+`
+	pkgs := setupPackages(t, code)
+
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "func VisitT(v T, visitor TVisitor) {")
+	assert.NotContains(t, string(src), "Number")
+
+	_, err = Generate(pkgs[0], Config{}, []string{"Number"})
+	assert.Error(t, err)
+}
+
+// TestGenerateSkipsSentinels tests that sum types of sentinel values are
+// skipped unless named explicitly, in which case generating code for them
+// fails.
+func TestGenerateSkipsSentinels(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "errors"
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+//sumtype:decl
+var ErrA, ErrB = errors.New("a"), errors.New("b")
+`
+	pkgs := setupPackages(t, code)
+
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "func VisitT(v T, visitor TVisitor) {")
+	assert.NotContains(t, string(src), "ErrA")
+
+	_, err = Generate(pkgs[0], Config{}, []string{"ErrA|ErrB"})
+	assert.Error(t, err)
+}
+
+// TestGenerateSkipsOneofStructs tests that oneof structs are skipped unless
+// named explicitly, in which case generating code for them fails.
+func TestGenerateSkipsOneofStructs(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+//sumtype:oneof
+type Value struct {
+	A *A
+	B *int
+}
+`
+	pkgs := setupPackages(t, code)
+
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "func VisitT(v T, visitor TVisitor) {")
+	assert.NotContains(t, string(src), "Value")
+
+	_, err = Generate(pkgs[0], Config{}, []string{"Value"})
+	assert.Error(t, err)
+}
+
+// TestGenerateDirectivePrefixes tests that sum types declared with
+// alternative directives are generated and checked with the config naming
+// them.
+func TestGenerateDirectivePrefixes(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//go-sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+`
+	pkgs := setupPackages(t, code)
+	config := Config{DirectivePrefixes: []string{"go-sumtype:decl"}}
+
+	_, err := Generate(pkgs[0], Config{}, nil)
+	assert.Error(t, err)
+	src, err := Generate(pkgs[0], config, nil)
+	assert.NoError(t, err)
+	assert.Contains(t, string(src), "func VisitT(v T, visitor TVisitor) {")
+
+	config.CheckGenerated = true
+	pkgs = loadGenerated(t, code, src)
+	assert.Equal(t, 0, len(Run(pkgs, config)))
+}
+
+const jsonCode = `
+package gochecksumtype
+
+//sumtype:decl json=kind
+type T interface { sealed() }
+
+//sumtype:tag alpha
+type A struct { X int }
+func (a *A) sealed() {}
+
+type B struct {}
+func (b B) sealed() {}
+`
+
+// TestGenerateJSON tests that the json option generates tagged union
+// marshalling with custom discriminator key and variant tags.
+func TestGenerateJSON(t *testing.T) {
+	pkgs := setupPackages(t, jsonCode)
+
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+	out := string(src)
+	assert.Contains(t, out, `"encoding/json"`)
+	assert.Contains(t, out, "func MarshalTJSON(v T) ([]byte, error) {")
+	assert.Contains(t, out, "func UnmarshalTJSON(data []byte) (T, error) {")
+	assert.Contains(t, out, "type TJSON struct {\n\tValue T\n}")
+	assert.Contains(t, out, "Tag string `json:\"kind\"`")
+	assert.Contains(t, out, "case \"alpha\":\n\t\tvar v A")
+	assert.Contains(t, out, "return &v, nil")
+	assert.Contains(t, out, "case \"B\":\n\t\tvar v B")
+	assert.Contains(t, out, "case B, *B:\n\t\ttag = \"B\"")
+
+	pkgs = loadGenerated(t, jsonCode, src)
+	assert.Equal(t, 0, len(pkgs[0].Errors))
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+}
+
+// TestGenerateDuplicateJSONTag tests that variants sharing a tag are rejected.
+func TestGenerateDuplicateJSONTag(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl json
+type T interface { sealed() }
+
+//sumtype:tag x
+type A struct {}
+func (a *A) sealed() {}
+
+//sumtype:tag x
+type B struct {}
+func (b *B) sealed() {}
+`
+	pkgs := setupPackages(t, code)
+
+	_, err := Generate(pkgs[0], Config{}, nil)
+	assert.Error(t, err)
+}
+
+// TestCheckGenerated tests that changes made after generating code are
+// reported with Config.CheckGenerated, as stale files when the code can be
+// regenerated and with the cause otherwise.
+func TestCheckGenerated(t *testing.T) {
+	pkgs := setupPackages(t, jsonCode)
+	src, err := Generate(pkgs[0], Config{}, nil)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		code   string
+		config Config
+		// want lists the types of the errors reported, in order.
+		want []string
+	}{
+		{
+			name:   "Unchanged",
+			code:   jsonCode,
+			config: Config{CheckGenerated: true},
+		},
+		{
+			name: "NewVariant",
+			code: jsonCode + `
+type C struct {}
+func (c *C) sealed() {}
+`,
+			config: Config{CheckGenerated: true},
+			want: []string{
+				"gochecksumtype.inexhaustiveError",
+				"gochecksumtype.inexhaustiveError",
+				"gochecksumtype.inexhaustiveError",
+				"gochecksumtype.staleGeneratedError",
+			},
+		},
+		{
+			name: "NewVariantUnchecked",
+			code: jsonCode + `
+type C struct {}
+func (c *C) sealed() {}
+`,
+			want: []string{
+				"gochecksumtype.inexhaustiveError",
+				"gochecksumtype.inexhaustiveError",
+				"gochecksumtype.inexhaustiveError",
+			},
+		},
+		{
+			name: "InvalidDecl",
+			code: jsonCode + `
+//sumtype:decl
+type N int
+`,
+			config: Config{CheckGenerated: true},
+			want: []string{
+				"gochecksumtype.notInterfaceError",
+				"gochecksumtype.generatedFileError",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkgs := loadGenerated(t, test.code, src)
+			var got []string
+			for _, err := range Run(pkgs, test.config) {
+				got = append(got, fmt.Sprintf("%T", err))
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+// loadGenerated type checks code together with generated source.
+func loadGenerated(t *testing.T, code string, generated []byte) []*packages.Package {
+	t.Helper()
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.go")
+	genPath := filepath.Join(dir, "sumtype_gen.go")
+	assert.NoError(t, os.WriteFile(srcPath, []byte(code), 0600))
+	assert.NoError(t, os.WriteFile(genPath, generated, 0600))
+	pkgs, err := tycheckAll([]string{srcPath, genPath})
+	assert.NoError(t, err)
+	return pkgs
+}
@@ -0,0 +1,103 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// unregisteredGobError corresponds to a sum type declared with the `gob`
+// option, some of whose variants are never passed to gob.Register or
+// gob.RegisterName in its declaring package.
+type unregisteredGobError struct {
+	Def     sumTypeDef
+	Missing []types.Object
+}
+
+func (e unregisteredGobError) Pos() token.Position { return e.Def.Decl.Pos }
+func (e unregisteredGobError) Error() string {
+	return fmt.Sprintf(
+		"%s: variants of sum type %q are not registered with encoding/gob: %s",
+		e.Pos(), e.Def.Decl.TypeName, strings.Join(e.Names(), ", "))
+}
+
+// Names returns a sorted list of names corresponding to the unregistered
+// variants.
+func (e unregisteredGobError) Names() []string {
+	return sortedNames(e.Missing)
+}
+
+// checkGobRegistrations reports sum types declared in pkg with the `gob`
+// option whose concrete variants are not all registered with encoding/gob.
+// Registrations are searched for in init functions and package level
+// variable initializers, and in functions of the same package they call.
+// Sum types of sentinel values and oneof structs are not checked, since gob
+// registers types.
+func checkGobRegistrations(pkg *packages.Package, defs []sumTypeDef) []error {
+	var gobDefs []*sumTypeDef
+	for i := range defs {
+		def := &defs[i]
+		if _, ok := def.Decl.Options["gob"]; ok && def.hasTypes() && def.Decl.Package.PkgPath == pkg.PkgPath {
+			gobDefs = append(gobDefs, def)
+		}
+	}
+	if len(gobDefs) == 0 {
+		return nil
+	}
+	var registered []types.Type
+	funcs := funcDecls(pkg)
+	collect := func(expr ast.Expr) {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if arg := gobRegisteredArg(pkg, call); arg != nil {
+			registered = append(registered, pkg.TypesInfo.TypeOf(arg))
+		}
+	}
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.Name == "init" && d.Body != nil {
+					inspectWithCallees(pkg, funcs, d.Body, collect)
+				}
+			case *ast.GenDecl:
+				if d.Tok == token.VAR {
+					inspectWithCallees(pkg, funcs, d, collect)
+				}
+			}
+		}
+	}
+	var errs []error
+	for _, def := range gobDefs {
+		if missing := def.missing(registered, false); len(missing) > 0 {
+			errs = append(errs, unregisteredGobError{Def: *def, Missing: missing})
+		}
+	}
+	return errs
+}
+
+// gobRegisteredArg returns the value registered by a call to gob.Register or
+// gob.RegisterName, or nil if call is neither.
+func gobRegisteredArg(pkg *packages.Package, call *ast.CallExpr) ast.Expr {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	fn, ok := pkg.TypesInfo.Uses[sel.Sel].(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "encoding/gob" {
+		return nil
+	}
+	switch {
+	case fn.Name() == "Register" && len(call.Args) == 1:
+		return call.Args[0]
+	case fn.Name() == "RegisterName" && len(call.Args) == 2:
+		return call.Args[1]
+	}
+	return nil
+}
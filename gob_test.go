@@ -0,0 +1,67 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestGobUnregistered tests that variants missing a gob registration are
+// reported with Config.CheckGobRegistrations for sum types declared with the
+// gob option.
+func TestGobUnregistered(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "encoding/gob"
+
+//sumtype:decl gob
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+var _ = register()
+
+func register() bool {
+	gob.RegisterName("b", &B{})
+	return true
+}
+
+func init() {
+	gob.Register(&A{})
+}
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{CheckGobRegistrations: true})
+	assert.Equal(t, 1, len(errs))
+	gerr, ok := errs[0].(unregisteredGobError)
+	assert.True(t, ok, "error was not unregisteredGobError: %T", errs[0])
+	assert.Equal(t, []string{"C"}, gerr.Names())
+}
+
+// TestGobNotOptedIn tests that sum types without the gob option are not
+// checked for registrations.
+func TestGobNotOptedIn(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{CheckGobRegistrations: true})
+	assert.Equal(t, 0, len(errs))
+}
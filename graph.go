@@ -0,0 +1,198 @@
+package gochecksumtype
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// GraphFormat selects the syntax used by WriteGraph.
+type GraphFormat string
+
+const (
+	// GraphDOT renders the hierarchy as a Graphviz digraph.
+	GraphDOT GraphFormat = "dot"
+	// GraphMermaid renders the hierarchy as a Mermaid flowchart.
+	GraphMermaid GraphFormat = "mermaid"
+)
+
+// WriteGraph writes a diagram of every sum type declared in the given
+// packages to w. Each sum type points at its intermediate interfaces, which in
+// turn point at the concrete variants implementing them. Types shared by
+// several sum types appear once, so nested sum types form a single DAG.
+func WriteGraph(w io.Writer, pkgs []*packages.Package, config Config, format GraphFormat) error {
+	defs, errs := sumTypes(pkgs, config)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	g := newGraph()
+	for i := range defs {
+		// Sum types of values or fields have no type hierarchy.
+		if defs[i].hasTypes() {
+			g.addSumType(&defs[i])
+		}
+	}
+	switch format {
+	case GraphDOT:
+		return g.writeDOT(w)
+	case GraphMermaid:
+		return g.writeMermaid(w)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+}
+
+type graphNode struct {
+	id    string
+	label string
+	iface bool
+	sum   bool
+}
+
+type graphEdge struct {
+	from, to *graphNode
+}
+
+// graph is a sum type hierarchy, with nodes keyed by their package qualified
+// type name.
+type graph struct {
+	nodes []*graphNode
+	index map[string]*graphNode
+	edges []graphEdge
+	seen  map[[2]string]bool
+}
+
+func newGraph() *graph {
+	return &graph{
+		index: map[string]*graphNode{},
+		seen:  map[[2]string]bool{},
+	}
+}
+
+func (g *graph) node(obj types.Object) *graphNode {
+	// Predeclared types, such as the terms of union constraints, have no
+	// package.
+	id, label := obj.Name(), obj.Name()
+	if obj.Pkg() != nil {
+		id = obj.Pkg().Path() + "." + obj.Name()
+		label = obj.Pkg().Name() + "." + obj.Name()
+	}
+	if n, ok := g.index[id]; ok {
+		return n
+	}
+	n := &graphNode{
+		id:    id,
+		label: label,
+		iface: isInterface(obj.Type()),
+	}
+	g.index[id] = n
+	g.nodes = append(g.nodes, n)
+	return n
+}
+
+func (g *graph) edge(from, to *graphNode) {
+	key := [2]string{from.id, to.id}
+	if g.seen[key] {
+		return
+	}
+	g.seen[key] = true
+	g.edges = append(g.edges, graphEdge{from, to})
+}
+
+// addSumType adds def and its variants to the graph. Every variant is linked
+// to the most specific interface variants it implements, or to the sum type
+// itself if it implements none.
+func (g *graph) addSumType(def *sumTypeDef) {
+	root := g.node(def.Decl.Package.Types.Scope().Lookup(def.Decl.TypeName))
+	root.sum = true
+	var ifaces []types.Object
+	for _, v := range def.Variants {
+		if isInterface(v.Type()) {
+			ifaces = append(ifaces, v)
+		}
+	}
+	for _, v := range def.Variants {
+		parents := mostSpecific(v, ifaces)
+		if len(parents) == 0 {
+			g.edge(root, g.node(v))
+			continue
+		}
+		for _, p := range parents {
+			g.edge(g.node(p), g.node(v))
+		}
+	}
+}
+
+// mostSpecific returns the interfaces in ifaces that v implements, excluding
+// any interface that is implied by another one in the result.
+func mostSpecific(v types.Object, ifaces []types.Object) []types.Object {
+	var candidates []types.Object
+	for _, iface := range ifaces {
+		if iface != v && implements(v.Type(), iface.Type()) {
+			candidates = append(candidates, iface)
+		}
+	}
+	var parents []types.Object
+	for _, c := range candidates {
+		implied := false
+		for _, other := range candidates {
+			if other != c && implements(other.Type(), c.Type()) && !implements(c.Type(), other.Type()) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			parents = append(parents, c)
+		}
+	}
+	return parents
+}
+
+func (g *graph) writeDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph sumtypes {\n")
+	for _, n := range g.nodes {
+		attrs := "shape=ellipse"
+		switch {
+		case n.sum:
+			attrs = "shape=box, style=bold"
+		case n.iface:
+			attrs = "shape=box"
+		}
+		fmt.Fprintf(&b, "\t%q [label=%q, %s];\n", n.id, n.label, attrs)
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "\t%q -> %q;\n", e.from.id, e.to.id)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (g *graph) writeMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart TD\n")
+	ids := make(map[*graphNode]string, len(g.nodes))
+	for i, n := range g.nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[n] = id
+		label := strings.ReplaceAll(n.label, `"`, "#quot;")
+		switch {
+		case n.sum:
+			fmt.Fprintf(&b, "\t%s{{\"%s\"}}\n", id, label)
+		case n.iface:
+			fmt.Fprintf(&b, "\t%s[/\"%s\"/]\n", id, label)
+		default:
+			fmt.Fprintf(&b, "\t%s[\"%s\"]\n", id, label)
+		}
+	}
+	for _, e := range g.edges {
+		fmt.Fprintf(&b, "\t%s --> %s\n", ids[e.from], ids[e.to])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,61 @@
+package gochecksumtype
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const graphCode = `
+package gochecksumtype
+
+//sumtype:decl
+type T1 interface { sealed1() }
+type T2 interface {
+  T1
+  sealed2()
+}
+
+type A struct {}
+func (a *A) sealed1() {}
+
+type B struct {}
+func (b *B) sealed1() {}
+func (b *B) sealed2() {}
+`
+
+// TestGraphDOT tests that intermediate interfaces sit between the sum type
+// and the variants implementing them.
+func TestGraphDOT(t *testing.T) {
+	pkgs := setupPackages(t, graphCode)
+
+	var b strings.Builder
+	err := WriteGraph(&b, pkgs, Config{}, GraphDOT)
+	assert.NoError(t, err)
+	out := b.String()
+	assert.Contains(t, out, `"command-line-arguments.T1" [label="gochecksumtype.T1", shape=box, style=bold];`)
+	assert.Contains(t, out, `"command-line-arguments.T2" [label="gochecksumtype.T2", shape=box];`)
+	assert.Contains(t, out, `"command-line-arguments.T1" -> "command-line-arguments.A";`)
+	assert.Contains(t, out, `"command-line-arguments.T1" -> "command-line-arguments.T2";`)
+	assert.Contains(t, out, `"command-line-arguments.T2" -> "command-line-arguments.B";`)
+	assert.NotContains(t, out, `"command-line-arguments.T1" -> "command-line-arguments.B";`)
+}
+
+// TestGraphMermaid tests the Mermaid rendering of a sum type hierarchy.
+func TestGraphMermaid(t *testing.T) {
+	pkgs := setupPackages(t, graphCode)
+
+	var b strings.Builder
+	err := WriteGraph(&b, pkgs, Config{}, GraphMermaid)
+	assert.NoError(t, err)
+	assert.Equal(t, `flowchart TD
+	n0{{"gochecksumtype.T1"}}
+	n1["gochecksumtype.A"]
+	n2[/"gochecksumtype.T2"/]
+	n3["gochecksumtype.B"]
+	n0 --> n1
+	n2 --> n3
+	n0 --> n2
+`, b.String())
+}
@@ -20,10 +20,37 @@ func setupPackages(t *testing.T, code string) []*packages.Package {
 	return pkgs
 }
 
+// setupModule writes the given files, keyed by slash separated path, into a
+// temporary module named "example.com/m" and loads all of its packages.
+func setupModule(t *testing.T, files map[string]string) []*packages.Package {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/m\n\ngo 1.24\n"
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkgs, err := tycheckDir(dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkgs
+}
+
 func tycheckAll(args []string) ([]*packages.Package, error) {
+	return tycheckDir("", args)
+}
+
+func tycheckDir(dir string, args []string) ([]*packages.Package, error) {
 	conf := &packages.Config{
+		Dir: dir,
 		Mode: packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedTypes | packages.NeedTypesSizes |
-			packages.NeedImports | packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
+			packages.NeedImports | packages.NeedDeps | packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles,
 		// Unfortunately, it appears including the test packages in
 		// this lint makes it difficult to do exhaustiveness checking.
 		// Namely, it appears that compiling the test version of a
@@ -0,0 +1,144 @@
+package gochecksumtype
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"io"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// findInferredDecls returns a sum type declaration for every sealed interface
+// in the given packages that has not been declared explicitly, has at least
+// one implementation, and is not implemented by any type in the other given
+// packages.
+//
+// If markerOnly is true, only interfaces with exactly one unexported method,
+// implemented by every variant with an empty body, are inferred.
+func findInferredDecls(pkgs []*packages.Package, declared []sumTypeDecl, markerOnly bool) []sumTypeDecl {
+	isDeclared := map[string]bool{}
+	for _, decl := range declared {
+		isDeclared[decl.Package.PkgPath+"."+decl.TypeName] = true
+	}
+	var decls []sumTypeDecl
+	for _, pkg := range pkgs {
+		scope := pkg.Types.Scope()
+		funcs := funcDecls(pkg)
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.IsAlias() || isDeclared[pkg.PkgPath+"."+name] {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams() != nil {
+				continue
+			}
+			iface, ok := obj.Type().Underlying().(*types.Interface)
+			if !ok || !iface.IsMethodSet() {
+				continue
+			}
+			sealer := sealingMethod(iface)
+			if sealer == nil {
+				continue
+			}
+			decl := sumTypeDecl{Package: pkg, TypeName: name, Pos: pkg.Fset.Position(obj.Pos()), Inferred: true}
+			def, err := newSumTypeDef(pkg.Types, decl, nil)
+			if err != nil || def == nil || len(def.Variants) == 0 {
+				continue
+			}
+			if implementedElsewhere(pkgs, pkg, iface) {
+				continue
+			}
+			if markerOnly && !hasMarkerMethod(funcs, def, iface, sealer) {
+				continue
+			}
+			debugf("inferred sum type: %s.%s", pkg.PkgPath, name)
+			decls = append(decls, decl)
+		}
+	}
+	return decls
+}
+
+// sealingMethod returns the first unexported method of iface, or nil if iface
+// is not sealed.
+func sealingMethod(iface *types.Interface) *types.Func {
+	for i := range iface.NumMethods() {
+		if m := iface.Method(i); !m.Exported() {
+			return m
+		}
+	}
+	return nil
+}
+
+// implementedElsewhere returns true if a concrete type declared in one of
+// pkgs other than home implements iface.
+func implementedElsewhere(pkgs []*packages.Package, home *packages.Package, iface *types.Interface) bool {
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == home.PkgPath {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || isInterface(obj.Type()) {
+				continue
+			}
+			if implements(obj.Type(), iface) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasMarkerMethod returns true if sealer is the only unexported method of
+// iface and every concrete variant of def implements it with an empty body.
+func hasMarkerMethod(funcs map[*types.Func]*ast.FuncDecl, def *sumTypeDef, iface *types.Interface, sealer *types.Func) bool {
+	for i := range iface.NumMethods() {
+		if m := iface.Method(i); m != sealer && !m.Exported() {
+			return false
+		}
+	}
+	for _, v := range def.Variants {
+		if isInterface(v.Type()) {
+			continue
+		}
+		sel := types.NewMethodSet(types.NewPointer(v.Type())).Lookup(sealer.Pkg(), sealer.Name())
+		if sel == nil {
+			return false
+		}
+		decl := funcs[sel.Obj().(*types.Func)]
+		if decl == nil || len(decl.Body.List) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// List writes one line per sum type found in the given packages, giving its
+// position, its fully qualified name, whether it was inferred rather than
+// declared, and its variants.
+func List(w io.Writer, pkgs []*packages.Package, config Config) error {
+	defs, errs := sumTypes(pkgs, config)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	for _, def := range defs {
+		var variants []string
+		for _, v := range def.Variants {
+			variants = append(variants, v.Name())
+		}
+		kind := "declared"
+		if def.Decl.Inferred {
+			kind = "inferred"
+		}
+		_, err := fmt.Fprintf(w, "%s: %s.%s (%s): %s\n",
+			def.Decl.Pos, def.Decl.Package.PkgPath, def.Decl.TypeName, kind, strings.Join(variants, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
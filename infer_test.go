@@ -0,0 +1,70 @@
+package gochecksumtype
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const inferCode = `
+package gochecksumtype
+
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() { println("not a marker") }
+
+type Unimplemented interface { unimplemented() }
+
+func main() {
+	switch T(nil).(type) {
+	case *A:
+	}
+}
+`
+
+// TestInfer tests that undeclared sealed interfaces are checked in infer
+// mode.
+func TestInfer(t *testing.T) {
+	pkgs := setupPackages(t, inferCode)
+
+	errs := Run(pkgs, Config{Infer: true})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+
+	errs = Run(pkgs, Config{})
+	assert.Equal(t, 0, len(errs))
+}
+
+// TestInferMarkerOnly tests that only interfaces with empty marker methods
+// are inferred when requested.
+func TestInferMarkerOnly(t *testing.T) {
+	pkgs := setupPackages(t, inferCode)
+
+	errs := Run(pkgs, Config{Infer: true, InferMarkerOnly: true})
+	assert.Equal(t, 0, len(errs))
+}
+
+// TestList tests that List distinguishes declared and inferred sum types.
+func TestList(t *testing.T) {
+	code := inferCode + `
+//sumtype:decl
+type U interface { sealedU() }
+
+type C struct {}
+func (c C) sealedU() {}
+`
+	pkgs := setupPackages(t, code)
+
+	var b strings.Builder
+	err := List(&b, pkgs, Config{Infer: true})
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Contains(t, lines[0], "command-line-arguments.U (declared): C")
+	assert.Contains(t, lines[1], "command-line-arguments.T (inferred): A, B")
+}
@@ -0,0 +1,166 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestMarkerSealing tests that variants embedding a marker type in other
+// packages are found, that the marker itself is not a variant, and that types
+// of packages importing the marker that implement the sum type without
+// embedding it are reported.
+func TestMarkerSealing(t *testing.T) {
+	pkgs := setupModule(t, map[string]string{
+		"shape/shape.go": `
+package shape
+
+//sumtype:decl
+type Shape interface { IsShape() }
+
+type Sealed struct { _ struct{} }
+func (Sealed) IsShape() {}
+
+type Square struct { Sealed }
+`,
+		"circle/circle.go": `
+package circle
+
+import "example.com/m/shape"
+
+type Circle struct {
+	shape.Sealed
+	Radius float64
+}
+
+type Ellipse struct {
+	shape.Sealed
+}
+
+type Fake struct{}
+func (Fake) IsShape() {}
+`,
+		"other/other.go": `
+package other
+
+type Unrelated struct{}
+func (Unrelated) IsShape() {}
+`,
+		"use/use.go": `
+package use
+
+import (
+	"example.com/m/circle"
+	"example.com/m/shape"
+)
+
+func Area(s shape.Shape) {
+	switch s.(type) {
+	case shape.Square, circle.Circle:
+	}
+}
+`,
+	})
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	berr, ok := errs[0].(markerBypassError)
+	assert.True(t, ok, "error was not markerBypassError: %T", errs[0])
+	assert.Equal(t, "Fake", berr.Type.Name())
+	assert.Equal(t, []string{"Ellipse"}, missingNames(t, errs[1]))
+}
+
+// TestMarkerOption tests that a marker can be named explicitly, and that an
+// interface without unexported methods or a marker is still unsealed.
+func TestMarkerOption(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl marker=sealed
+type T interface { IsT() }
+
+type sealed struct {}
+func (sealed) IsT() {}
+
+type A struct { sealed }
+type B struct { sealed }
+
+//sumtype:decl
+type U interface { IsU() }
+
+func main() {
+	switch T(nil).(type) {
+	case A:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "U", errs[0].(unsealedError).Decl.TypeName)
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[1]))
+}
+
+// TestMarkerDeclaringPackage tests that a marker embedded by variants of its
+// own package is recognised when the declaring package is checked on its own.
+func TestMarkerDeclaringPackage(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type Shape interface { IsShape() }
+
+type Sealed struct { _ struct{} }
+func (Sealed) IsShape() {}
+
+type Square struct { Sealed }
+type Circle struct { Sealed }
+
+func main() {
+	switch Shape(nil).(type) {
+	case Square:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"Circle"}, missingNames(t, errs[0]))
+}
+
+// TestMarkerNotFound tests that an interface with only exported methods is
+// reported with its candidate markers when none of them is embedded, or when
+// the marker option names no marker.
+func TestMarkerNotFound(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { IsT() }
+
+type Sealed struct { _ struct{} }
+func (Sealed) IsT() {}
+
+//sumtype:decl marker=sealed
+type U interface { IsU() }
+
+type A struct {}
+func (A) IsU() {}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	merr, ok := errs[0].(markerNotFoundError)
+	assert.True(t, ok, "error was not markerNotFoundError: %T", errs[0])
+	assert.Equal(t, "T", merr.Decl.TypeName)
+	assert.Equal(t, 1, len(merr.Candidates))
+	assert.Equal(t, "Sealed", merr.Candidates[0].Name())
+	assert.Contains(t, merr.Error(), "marker=Sealed")
+	merr, ok = errs[1].(markerNotFoundError)
+	assert.True(t, ok, "error was not markerNotFoundError: %T", errs[1])
+	assert.Equal(t, "U", merr.Decl.TypeName)
+	assert.Contains(t, merr.Error(), "marker sealed")
+}
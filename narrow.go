@@ -0,0 +1,258 @@
+package gochecksumtype
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/cfg"
+	"golang.org/x/tools/go/packages"
+)
+
+// exclusions maps each variant that a variable cannot hold to the position of
+// the type assertion that ruled it out.
+type exclusions map[types.Object]token.Pos
+
+// narrow removes from missing the variants that the variable switched on by
+// swtch cannot hold on any path reaching the switch, because an earlier
+// check such as
+//
+//	if _, ok := x.(*Nop); ok {
+//		return
+//	}
+//
+// ruled them out. The remaining missing variants are returned along with the
+// position of the check excluding each removed one.
+//
+// Only switches over local variables and parameters whose address is not
+// taken, and which are not assigned in closures, are narrowed.
+func narrow(
+	pkg *packages.Package,
+	def *sumTypeDef,
+	swtch *ast.TypeSwitchStmt,
+	missing []types.Object,
+) ([]types.Object, map[types.Object]token.Position) {
+	ident, ok := ast.Unparen(findTypeAssertExpr(swtch)).(*ast.Ident)
+	if !ok {
+		return missing, nil
+	}
+	v, ok := pkg.TypesInfo.Uses[ident].(*types.Var)
+	if !ok || v.Parent() == v.Pkg().Scope() {
+		return missing, nil
+	}
+	body := enclosingFuncBody(pkg, swtch)
+	if body == nil || escapes(pkg, body, v) {
+		return missing, nil
+	}
+	n := &narrowing{pkg: pkg, def: def, v: v}
+	excluded := n.excludedAt(cfg.New(body, n.mayReturn), swtch.Assign)
+	var remaining []types.Object
+	positions := map[types.Object]token.Position{}
+	for _, variant := range missing {
+		if pos, ok := excluded[variant]; ok {
+			positions[variant] = pkg.Fset.Position(pos)
+		} else {
+			remaining = append(remaining, variant)
+		}
+	}
+	return remaining, positions
+}
+
+// enclosingFuncBody returns the body of the innermost function declaration
+// or literal of pkg containing node.
+func enclosingFuncBody(pkg *packages.Package, node ast.Node) *ast.BlockStmt {
+	for _, file := range pkg.Syntax {
+		if node.Pos() < file.FileStart || node.End() > file.FileEnd {
+			continue
+		}
+		path, _ := astutil.PathEnclosingInterval(file, node.Pos(), node.End())
+		for _, n := range path {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				return n.Body
+			case *ast.FuncLit:
+				return n.Body
+			}
+		}
+	}
+	return nil
+}
+
+// escapes returns true if the address of v is taken in body, or v is
+// assigned in a function literal within body, either of which may change v
+// in ways not visible in the control flow of body.
+func escapes(pkg *packages.Package, body *ast.BlockStmt, v *types.Var) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if ident, ok := ast.Unparen(n.X).(*ast.Ident); ok && n.Op == token.AND && pkg.TypesInfo.ObjectOf(ident) == v {
+				found = true
+			}
+		case *ast.FuncLit:
+			if assigns(pkg, n.Body, v) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// assigns returns true if node assigns to v, or names v on its own, as the
+// key or value of a range statement does in a control flow graph.
+func assigns(pkg *packages.Package, node ast.Node, v *types.Var) bool {
+	if ident, ok := node.(*ast.Ident); ok {
+		return pkg.TypesInfo.ObjectOf(ident) == v
+	}
+	found := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		var lhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			lhs = n.Lhs
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				lhs = append(lhs, name)
+			}
+		}
+		for _, expr := range lhs {
+			if ident, ok := ast.Unparen(expr).(*ast.Ident); ok && pkg.TypesInfo.ObjectOf(ident) == v {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// narrowing tracks the variants of a sum type that the variable v cannot
+// hold through the control flow graph of a function.
+type narrowing struct {
+	pkg *packages.Package
+	def *sumTypeDef
+	v   *types.Var
+}
+
+// mayReturn reports whether a call may return, which calls of panic do not.
+func (n *narrowing) mayReturn(call *ast.CallExpr) bool {
+	ident, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	return !ok || n.pkg.TypesInfo.Uses[ident] != types.Universe.Lookup("panic")
+}
+
+// excludedAt returns the variants that v cannot hold on any path through g
+// reaching the given node.
+func (n *narrowing) excludedAt(g *cfg.CFG, node ast.Node) exclusions {
+	in := map[*cfg.Block]exclusions{g.Blocks[0]: {}}
+	work := []*cfg.Block{g.Blocks[0]}
+	for len(work) > 0 {
+		b := work[0]
+		work = work[1:]
+		out := n.transfer(in[b], b.Nodes)
+		guarded, edge, pos := n.guard(b)
+		for i, succ := range b.Succs {
+			facts := out
+			if len(guarded) > 0 && i == edge {
+				facts = maps.Clone(out)
+				for _, variant := range guarded {
+					facts[variant] = pos
+				}
+			}
+			if merged, changed := merge(in[succ], facts); changed {
+				in[succ] = merged
+				work = append(work, succ)
+			}
+		}
+	}
+	for _, b := range g.Blocks {
+		if i := slices.Index(b.Nodes, node); i >= 0 && in[b] != nil {
+			return n.transfer(in[b], b.Nodes[:i])
+		}
+	}
+	return nil
+}
+
+// transfer returns the exclusions holding after the given nodes, which are
+// reset by any assignment to v.
+func (n *narrowing) transfer(facts exclusions, nodes []ast.Node) exclusions {
+	for _, node := range nodes {
+		if assigns(n.pkg, node, n.v) {
+			facts = exclusions{}
+		}
+	}
+	return facts
+}
+
+// guard recognises a block ending in the condition `ok` or `!ok`, where ok
+// was assigned in the same block by a type assertion `_, ok := v.(T)`. It
+// returns the variants matching T, the index of the successor edge on which
+// v does not hold T, and the position of the type assertion.
+func (n *narrowing) guard(b *cfg.Block) ([]types.Object, int, token.Pos) {
+	if len(b.Succs) != 2 || len(b.Nodes) == 0 {
+		return nil, 0, token.NoPos
+	}
+	cond, ok := b.Nodes[len(b.Nodes)-1].(ast.Expr)
+	if !ok {
+		return nil, 0, token.NoPos
+	}
+	edge := 1
+	if not, ok := ast.Unparen(cond).(*ast.UnaryExpr); ok && not.Op == token.NOT {
+		cond, edge = not.X, 0
+	}
+	ident, ok := ast.Unparen(cond).(*ast.Ident)
+	if !ok {
+		return nil, 0, token.NoPos
+	}
+	okVar := n.pkg.TypesInfo.ObjectOf(ident)
+	for _, node := range slices.Backward(b.Nodes[:len(b.Nodes)-1]) {
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok || len(assign.Lhs) != 2 || len(assign.Rhs) != 1 {
+			continue
+		}
+		lhs, ok := assign.Lhs[1].(*ast.Ident)
+		if !ok || n.pkg.TypesInfo.ObjectOf(lhs) != okVar {
+			continue
+		}
+		assert, ok := ast.Unparen(assign.Rhs[0]).(*ast.TypeAssertExpr)
+		if !ok || assert.Type == nil {
+			return nil, 0, token.NoPos
+		}
+		x, ok := ast.Unparen(assert.X).(*ast.Ident)
+		if !ok || n.pkg.TypesInfo.ObjectOf(x) != n.v {
+			return nil, 0, token.NoPos
+		}
+		return n.matching(n.pkg.TypesInfo.TypeOf(assert.Type)), edge, assert.Pos()
+	}
+	return nil, 0, token.NoPos
+}
+
+// matching returns the concrete variants that a case for ty would cover.
+func (n *narrowing) matching(ty types.Type) []types.Object {
+	unmatched := n.def.missing([]types.Type{ty}, false)
+	var matched []types.Object
+	for _, variant := range n.def.Variants {
+		if !isInterface(variant.Type()) && !slices.Contains(unmatched, variant) {
+			matched = append(matched, variant)
+		}
+	}
+	return matched
+}
+
+// merge combines the exclusions of a block reached along another edge with
+// those already known for it, keeping only those holding on every edge.
+func merge(known, facts exclusions) (exclusions, bool) {
+	if known == nil {
+		return maps.Clone(facts), true
+	}
+	changed := false
+	for variant := range known {
+		if _, ok := facts[variant]; !ok {
+			delete(known, variant)
+			changed = true
+		}
+	}
+	return known, changed
+}
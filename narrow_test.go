@@ -0,0 +1,76 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestNarrow tests that variants ruled out on every path reaching a type
+// switch need not be covered with Config.Narrow.
+func TestNarrow(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type Nop struct {}
+func (n *Nop) sealed() {}
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func early(x T) {
+	if _, ok := x.(*Nop); ok {
+		return
+	}
+	switch x.(type) {
+	case *A:
+	}
+}
+
+func negated(x T) {
+	if _, ok := x.(*Nop); !ok {
+		switch x.(type) {
+		case *A, *B:
+		}
+	}
+}
+
+func onePath(x T, c bool) {
+	if c {
+		if _, ok := x.(*Nop); ok {
+			return
+		}
+	}
+	switch x.(type) {
+	case *A, *B:
+	}
+}
+
+func reassigned(x T) {
+	if _, ok := x.(*Nop); ok {
+		return
+	}
+	x = &Nop{}
+	switch x.(type) {
+	case *A, *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{Narrow: true})
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+	assert.Contains(t, errs[0].Error(), "excluded Nop by check at ")
+	assert.Equal(t, []string{"Nop"}, missingNames(t, errs[1]))
+	assert.Equal(t, []string{"Nop"}, missingNames(t, errs[2]))
+
+	errs = Run(pkgs, Config{})
+	assert.Equal(t, 4, len(errs))
+}
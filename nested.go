@@ -0,0 +1,61 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/types"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// subSums returns the interface variants among variants. Each is a sum type
+// nested in the sum type, whose variants are the variants of the sum type
+// implementing it, so that a case for it covers them all.
+func subSums(variants []types.Object) []types.Object {
+	var subs []types.Object
+	for _, v := range variants {
+		if _, ok := v.(*types.TypeName); ok && isInterface(v.Type()) {
+			subs = append(subs, v)
+		}
+	}
+	return subs
+}
+
+// isSubSum returns true if ty is one of the interface variants of def.
+func (def *sumTypeDef) isSubSum(ty types.Type) bool {
+	return slices.ContainsFunc(def.SubSums, func(sub types.Object) bool {
+		return types.Identical(sub.Type(), ty)
+	})
+}
+
+// groupedNames returns the names of the missing variants, with those
+// implementing a single most specific sub-sum of the sum type grouped under
+// it, as in "A, Sub (B, C)".
+func (e inexhaustiveError) groupedNames() []string {
+	subs := e.Def.SubSums
+	groups := map[string][]string{}
+	var names []string
+	for _, m := range e.Missing {
+		var parents []types.Object
+		if _, ok := m.(*types.TypeName); ok {
+			parents = mostSpecific(m, subs)
+		}
+		if len(parents) != 1 {
+			names = append(names, m.Name())
+			continue
+		}
+		sub := parents[0].Name()
+		if groups[sub] == nil {
+			names = append(names, sub)
+		}
+		groups[sub] = append(groups[sub], m.Name())
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if leaves, ok := groups[name]; ok {
+			sort.Strings(leaves)
+			names[i] = fmt.Sprintf("%s (%s)", name, strings.Join(leaves, ", "))
+		}
+	}
+	return names
+}
@@ -0,0 +1,60 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestNestedSumType tests that a case for a nested sum type covers its
+// variants, and that missing variants are grouped under it.
+func TestNestedSumType(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+//sumtype:decl
+type Sub interface {
+	T
+	sealedSub()
+}
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+func (b *B) sealedSub() {}
+
+type C struct {}
+func (c *C) sealed() {}
+func (c *C) sealedSub() {}
+
+func main() {
+	switch T(nil).(type) {
+	case *A, Sub:
+	}
+	switch T(nil).(type) {
+	case *A, *B, *C:
+	}
+	switch T(nil).(type) {
+	case Sub:
+	}
+	switch T(nil).(type) {
+	case *B:
+	}
+	switch T(nil).(type) {
+	case *A:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 3, len(errs))
+	assert.Contains(t, errs[0].Error(), "missing cases for A")
+	assert.Contains(t, errs[1].Error(), "missing cases for A, Sub (C)")
+	assert.Contains(t, errs[2].Error(), "missing cases for Sub (B, C)")
+}
@@ -0,0 +1,306 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// notStructError corresponds to a `//sumtype:oneof` declaration of a type
+// that is not a struct.
+type notStructError struct {
+	Decl sumTypeDecl
+}
+
+func (e notStructError) Pos() token.Position { return e.Decl.Pos }
+func (e notStructError) Error() string {
+	return fmt.Sprintf("%s: oneof type %q is not a struct", e.Pos(), e.Decl.TypeName)
+}
+
+// oneofFieldError corresponds to a field of a oneof struct that cannot be
+// nil, and so cannot be left unset.
+type oneofFieldError struct {
+	Decl  sumTypeDecl
+	Field *types.Var
+}
+
+func (e oneofFieldError) Pos() token.Position {
+	return e.Decl.Package.Fset.Position(e.Field.Pos())
+}
+
+func (e oneofFieldError) Error() string {
+	return fmt.Sprintf("%s: field %s of oneof struct %q cannot be nil", e.Pos(), e.Field.Name(), e.Decl.TypeName)
+}
+
+// oneofLiteralError corresponds to a composite literal of a oneof struct that
+// does not set exactly one field.
+type oneofLiteralError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Set      []types.Object
+}
+
+func (e oneofLiteralError) Pos() token.Position { return e.Position }
+func (e oneofLiteralError) Error() string {
+	set := "none"
+	if len(e.Set) > 0 {
+		set = strings.Join(sortedNames(e.Set), ", ")
+	}
+	return fmt.Sprintf(
+		"%s: exactly one field of oneof struct %q (from %s) must be set, found %s",
+		e.Pos(), e.Def.Decl.TypeName, e.Def.Decl.Pos, set)
+}
+
+// findOneofStructDecls returns a declaration for every type in the given
+// packages documented with the `//sumtype:oneof` directive, which models a
+// union as a struct of nilable fields of which exactly one is set. In a
+// grouped type declaration, each type is documented by its own comment.
+func findOneofStructDecls(pkgs []*packages.Package) []sumTypeDecl {
+	var decls []sumTypeDecl
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, d := range file.Decls {
+				decl, ok := d.(*ast.GenDecl)
+				if !ok || decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					tspec := spec.(*ast.TypeSpec)
+					doc := tspec.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					if doc == nil {
+						continue
+					}
+					for _, line := range doc.List {
+						if _, ok := directiveArgs(line.Text, "sumtype:oneof"); !ok {
+							continue
+						}
+						debugf("found oneof struct decl: %s.%s", pkg.PkgPath, tspec.Name.Name)
+						decls = append(decls, sumTypeDecl{
+							Package:  pkg,
+							TypeName: tspec.Name.Name,
+							Pos:      pkg.Fset.Position(tspec.Pos()),
+							Kind:     kindOneof,
+						})
+						break
+					}
+				}
+			}
+		}
+	}
+	return decls
+}
+
+// newOneofStructDef returns the definition of a oneof struct, whose variants
+// are its fields. Every field must be nilable.
+func newOneofStructDef(pkg *types.Package, decl sumTypeDecl) (*sumTypeDef, error) {
+	obj := pkg.Scope().Lookup(decl.TypeName)
+	if obj == nil {
+		return nil, nil
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, notStructError{decl}
+	}
+	def := &sumTypeDef{Decl: decl, Kind: kindOneof, ByValue: map[types.Object]bool{}}
+	for i := range st.NumFields() {
+		field := st.Field(i)
+		if !isNilable(field.Type()) {
+			return nil, oneofFieldError{Decl: decl, Field: field}
+		}
+		def.Variants = append(def.Variants, field)
+	}
+	return def, nil
+}
+
+// isNilable returns true if nil is a valid value of ty.
+func isNilable(ty types.Type) bool {
+	switch ty.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Slice, *types.Map, *types.Chan, *types.Signature:
+		return true
+	}
+	return false
+}
+
+// oneofDef returns the definition of the oneof struct ty, or of the struct ty
+// points to, or nil if there is none.
+func oneofDef(defs []sumTypeDef, ty types.Type) *sumTypeDef {
+	for i := range defs {
+		def := &defs[i]
+		if def.Kind != kindOneof {
+			continue
+		}
+		obj := def.Decl.Package.Types.Scope().Lookup(def.Decl.TypeName)
+		if types.Identical(indirect(ty), obj.Type()) {
+			return def
+		}
+	}
+	return nil
+}
+
+// checkOneofLiterals reports every composite literal of a oneof struct in
+// pkg that does not set exactly one field to a value other than nil. It is
+// enabled by Config.CheckOneofLiterals.
+func checkOneofLiterals(pkg *packages.Package, defs []sumTypeDef) []error {
+	var errs []error
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			def := oneofDef(defs, pkg.TypesInfo.TypeOf(lit))
+			if def == nil {
+				return true
+			}
+			var set []types.Object
+			for i, elt := range lit.Elts {
+				field := def.Variants[i]
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					field = pkg.TypesInfo.ObjectOf(kv.Key.(*ast.Ident))
+					elt = kv.Value
+				}
+				if !pkg.TypesInfo.Types[elt].IsNil() {
+					set = append(set, field)
+				}
+			}
+			if len(set) != 1 {
+				errs = append(errs, oneofLiteralError{
+					Position: pkg.Fset.Position(lit.Pos()),
+					Def:      *def,
+					Set:      set,
+				})
+			}
+			return true
+		})
+	}
+	return errs
+}
+
+// checkOneofChains reports chains of conditions testing whether fields of a
+// oneof struct are set, found as described by condChains, that do not test
+// every field. As with errors.As, chains of a single test are ignored.
+func checkOneofChains(pkg *packages.Package, defs []sumTypeDef, config Config) []error {
+	bound := oneofFieldVars(pkg, defs)
+	match := func(cond ast.Expr) (string, ast.Expr, bool) {
+		x, ok := presenceTest(pkg, cond)
+		if !ok {
+			return "", nil, false
+		}
+		if ident, ok := x.(*ast.Ident); ok {
+			if sel, ok := bound[pkg.TypesInfo.Uses[ident]]; ok {
+				x = sel
+			}
+		}
+		sel, ok := x.(*ast.SelectorExpr)
+		if !ok || !isOneofField(pkg, defs, sel) {
+			return "", nil, false
+		}
+		return types.ExprString(sel.X), sel, true
+	}
+	var errs []error
+	for _, chain := range condChains(pkg, match) {
+		if len(chain.targets) < 2 || (config.DefaultSignifiesExhaustive && chain.hasDefault) {
+			continue
+		}
+		def := oneofDef(defs, pkg.TypesInfo.Selections[chain.targets[0].(*ast.SelectorExpr)].Recv())
+		covered := map[types.Object]bool{}
+		for _, target := range chain.targets {
+			covered[pkg.TypesInfo.Selections[target.(*ast.SelectorExpr)].Obj()] = true
+		}
+		var missing []types.Object
+		for _, field := range def.Variants {
+			if !covered[field] {
+				missing = append(missing, field)
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, inexhaustiveError{
+				Position: pkg.Fset.Position(chain.node.Pos()),
+				Def:      *def,
+				Missing:  missing,
+			})
+		}
+	}
+	return errs
+}
+
+// presenceTest returns the operand of cond if cond tests whether a value is
+// set, in the form `x != nil`, or `len(x) != 0` or `len(x) > 0` for slices,
+// maps and channels.
+func presenceTest(pkg *packages.Package, cond ast.Expr) (ast.Expr, bool) {
+	bin, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok {
+		return nil, false
+	}
+	x, y := ast.Unparen(bin.X), ast.Unparen(bin.Y)
+	op := bin.Op
+	if pkg.TypesInfo.Types[x].Value != nil || pkg.TypesInfo.Types[x].IsNil() {
+		x, y = y, x
+		if op == token.LSS {
+			op = token.GTR
+		}
+	}
+	if op == token.NEQ && pkg.TypesInfo.Types[y].IsNil() {
+		return x, true
+	}
+	if op != token.NEQ && op != token.GTR {
+		return nil, false
+	}
+	zero := pkg.TypesInfo.Types[y].Value
+	if zero == nil || zero.String() != "0" {
+		return nil, false
+	}
+	call, ok := x.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, false
+	}
+	if fn, ok := ast.Unparen(call.Fun).(*ast.Ident); !ok || pkg.TypesInfo.Uses[fn] != types.Universe.Lookup("len") {
+		return nil, false
+	}
+	return ast.Unparen(call.Args[0]), true
+}
+
+// isOneofField returns true if sel selects a field of a oneof struct.
+func isOneofField(pkg *packages.Package, defs []sumTypeDef, sel *ast.SelectorExpr) bool {
+	selection := pkg.TypesInfo.Selections[sel]
+	if selection == nil || selection.Kind() != types.FieldVal || len(selection.Index()) != 1 {
+		return false
+	}
+	return oneofDef(defs, selection.Recv()) != nil
+}
+
+// oneofFieldVars maps the local variables of pkg declared with a field of a
+// oneof struct as their initial value, as in `if a := x.A; a != nil`, to
+// the selector of that field.
+func oneofFieldVars(pkg *packages.Package, defs []sumTypeDef) map[types.Object]*ast.SelectorExpr {
+	bound := map[types.Object]*ast.SelectorExpr{}
+	for _, file := range pkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			assign, ok := n.(*ast.AssignStmt)
+			if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != len(assign.Rhs) {
+				return true
+			}
+			for i, lhs := range assign.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				sel, ok := ast.Unparen(assign.Rhs[i]).(*ast.SelectorExpr)
+				if ok && isOneofField(pkg, defs, sel) {
+					if obj := pkg.TypesInfo.Defs[ident]; obj != nil {
+						bound[obj] = sel
+					}
+				}
+			}
+			return true
+		})
+	}
+	return bound
+}
@@ -0,0 +1,176 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const oneofValue = `
+package gochecksumtype
+
+type A struct {}
+type B struct {}
+
+//sumtype:oneof
+type Value struct {
+	A *A
+	B *B
+	C []int
+}
+`
+
+// TestOneofLiterals tests that composite literals of oneof structs must set
+// exactly one field with Config.CheckOneofLiterals.
+func TestOneofLiterals(t *testing.T) {
+	code := oneofValue + `
+var (
+	one        = Value{A: &A{}}
+	positional = Value{nil, &B{}, nil}
+	none       = &Value{}
+	two        = Value{A: &A{}, C: []int{1}}
+)
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{CheckOneofLiterals: true})
+	assert.Equal(t, 2, len(errs))
+	lerr, ok := errs[0].(oneofLiteralError)
+	assert.True(t, ok, "error was not oneofLiteralError: %T", errs[0])
+	assert.Equal(t, 0, len(lerr.Set))
+	lerr, ok = errs[1].(oneofLiteralError)
+	assert.True(t, ok, "error was not oneofLiteralError: %T", errs[1])
+	assert.Equal(t, []string{"A", "C"}, sortedNames(lerr.Set))
+}
+
+// TestOneofChains tests that chains testing whether the fields of a oneof
+// struct are set must test every field, through nil comparisons, lengths or
+// variables bound in the chain, while single tests are not chains.
+func TestOneofChains(t *testing.T) {
+	code := oneofValue + `
+func ifElse(x Value) {
+	if x.A != nil {
+	} else if x.B != nil {
+	}
+}
+
+func switchAll(x Value) {
+	switch {
+	case x.A != nil:
+	case nil != x.B:
+	case x.C != nil:
+	}
+}
+
+func length(x Value) {
+	switch {
+	case x.A != nil:
+	case len(x.C) > 0:
+	}
+}
+
+func lenNotZero(x Value) {
+	if x.A != nil {
+	} else if x.B != nil {
+	} else if 0 != len(x.C) {
+	}
+}
+
+func bound(x Value) {
+	if a := x.A; a != nil {
+	} else if b := x.B; b != nil {
+	}
+}
+
+func switchInit(x Value) {
+	switch a, b, c := x.A, x.B, x.C; {
+	case a != nil:
+	case b != nil:
+	case len(c) != 0:
+	}
+}
+
+func single(x Value) {
+	if x.A != nil {
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, []string{"C"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[1]))
+	assert.Equal(t, []string{"C"}, missingNames(t, errs[2]))
+}
+
+// TestOneofGroupedDecls tests that each struct of a grouped type declaration
+// is documented by its own comment, rather than by the comment of the group.
+func TestOneofGroupedDecls(t *testing.T) {
+	code := `
+package gochecksumtype
+
+type (
+	//sumtype:oneof
+	Value struct {
+		A *int
+		B *string
+	}
+
+	Plain struct {
+		A *int
+		B int
+	}
+)
+
+//sumtype:oneof
+type (
+	Other struct {
+		A *int
+		B *string
+	}
+
+	OtherPlain struct {
+		A *int
+		B int
+	}
+)
+
+var (
+	_ = Value{}
+	_ = Other{}
+)
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{CheckOneofLiterals: true})
+	assert.Equal(t, 1, len(errs))
+	_, ok := errs[0].(oneofLiteralError)
+	assert.True(t, ok, "error was not oneofLiteralError: %T", errs[0])
+}
+
+// TestOneofInvalidDecls tests that oneof structs with fields that cannot be
+// nil, and oneof directives on types that are not structs, are rejected.
+func TestOneofInvalidDecls(t *testing.T) {
+	code := `
+package gochecksumtype
+
+//sumtype:oneof
+type Value struct {
+	A *int
+	B int
+}
+
+//sumtype:oneof
+type Kind int
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	_, ok := errs[0].(oneofFieldError)
+	assert.True(t, ok, "error was not oneofFieldError: %T", errs[0])
+	_, ok = errs[1].(notStructError)
+	assert.True(t, ok, "error was not notStructError: %T", errs[1])
+}
@@ -0,0 +1,64 @@
+package gochecksumtype
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"regexp"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// oneofInterfaceName matches the names protoc-gen-go gives to the interfaces
+// of oneof fields, e.g. isFoo_Bar for field bar of message Foo.
+var oneofInterfaceName = regexp.MustCompile(`^is[A-Z]\w*_\w+$`)
+
+// findOneofDecls returns a sum type declaration for every oneof interface
+// generated by protoc-gen-go in the given packages or their dependencies.
+// Such an interface has a single unexported method named after itself, and
+// is declared in a file marked as generated. Since a oneof may be unset, the
+// declarations require type switches to handle nil.
+func findOneofDecls(pkgs []*packages.Package) []sumTypeDecl {
+	var decls []sumTypeDecl
+	generated := map[string]bool{}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types == nil {
+			return
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !oneofInterfaceName.MatchString(name) {
+				continue
+			}
+			iface, ok := obj.Type().Underlying().(*types.Interface)
+			if !ok || iface.NumMethods() != 1 || iface.Method(0).Name() != name {
+				continue
+			}
+			pos := pkg.Fset.Position(obj.Pos())
+			isGenerated, ok := generated[pos.Filename]
+			if !ok {
+				isGenerated = isGeneratedFile(pos.Filename)
+				generated[pos.Filename] = isGenerated
+			}
+			if !isGenerated {
+				continue
+			}
+			decl := sumTypeDecl{Package: pkg, TypeName: name, Pos: pos, Options: map[string]string{"nil": ""}}
+			debugf("found protobuf oneof: %s.%s", pkg.PkgPath, name)
+			decls = append(decls, decl)
+		}
+	})
+	return decls
+}
+
+// isGeneratedFile reports whether the Go file with the given name starts with
+// a "Code generated ... DO NOT EDIT." comment.
+func isGeneratedFile(filename string) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly|parser.ParseComments)
+	if err != nil {
+		return false
+	}
+	return ast.IsGenerated(file)
+}
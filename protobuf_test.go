@@ -0,0 +1,75 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const oneofCode = `// Code generated by protoc-gen-go. DO NOT EDIT.
+
+package gochecksumtype
+
+type Foo struct {
+	Bar isFoo_Bar
+}
+
+type isFoo_Bar interface {
+	isFoo_Bar()
+}
+
+type Foo_X struct { X int64 }
+type Foo_Y struct { Y string }
+
+func (*Foo_X) isFoo_Bar() {}
+func (*Foo_Y) isFoo_Bar() {}
+
+func main() {
+	switch (&Foo{}).Bar.(type) {
+	case *Foo_X:
+	}
+}
+`
+
+// TestProtobufOneof tests that generated oneof interfaces are checked for
+// every wrapper type and the unset case.
+func TestProtobufOneof(t *testing.T) {
+	pkgs := setupPackages(t, oneofCode)
+
+	errs := Run(pkgs, Config{Protobuf: true})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"Foo_Y", "nil"}, missingNames(t, errs[0]))
+}
+
+// TestProtobufOneofDisabled tests that oneof interfaces are ignored unless
+// the protobuf mode is enabled.
+func TestProtobufOneofDisabled(t *testing.T) {
+	pkgs := setupPackages(t, oneofCode)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 0, len(errs))
+}
+
+// TestProtobufNotGenerated tests that interfaces shaped like oneofs outside
+// generated files are not treated as sum types.
+func TestProtobufNotGenerated(t *testing.T) {
+	code := `
+package gochecksumtype
+
+type isFoo_Bar interface {
+	isFoo_Bar()
+}
+
+type Foo_X struct {}
+func (*Foo_X) isFoo_Bar() {}
+
+func main() {
+	switch isFoo_Bar(nil).(type) {
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{Protobuf: true})
+	assert.Equal(t, 0, len(errs))
+}
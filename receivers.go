@@ -0,0 +1,326 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ambiguousReceiverError corresponds to a variant whose value and pointer
+// types both implement a sum type, and that is stored in it in both forms, so
+// that a type switch needs to handle both.
+type ambiguousReceiverError struct {
+	Def        sumTypeDef
+	Variant    types.Object
+	ValueUse   token.Position
+	PointerUse token.Position
+}
+
+func (e ambiguousReceiverError) Pos() token.Position {
+	return e.Def.Decl.Package.Fset.Position(e.Variant.Pos())
+}
+func (e ambiguousReceiverError) Error() string {
+	return fmt.Sprintf(
+		"%s: variant %s of sum type %q (from %s) is stored both as %s (at %s) and as *%s (at %s), "+
+			"use one form consistently or pointer receivers so only one form can be stored",
+		e.Pos(), e.Variant.Name(), e.Def.Decl.TypeName, e.Def.Decl.Pos,
+		e.Variant.Name(), e.ValueUse, e.Variant.Name(), e.PointerUse)
+}
+
+// receiverFormError corresponds to a type switch case naming a variant in a
+// different form, value or pointer, than the one it is stored in the sum type
+// with, which for variants with pointer receivers is always the pointer form.
+type receiverFormError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Variant  types.Object
+	Case     types.Type
+	// Pointer is true if the variant must be named by pointer.
+	Pointer bool
+}
+
+func (e receiverFormError) Pos() token.Position { return e.Position }
+func (e receiverFormError) Error() string {
+	want := e.Variant.Name()
+	if e.Pointer {
+		want = "*" + want
+	}
+	return fmt.Sprintf(
+		"%s: case %s does not match the form variant %s is stored in sum type %q (from %s) with, use case %s",
+		e.Pos(), types.TypeString(e.Case, types.RelativeTo(e.Variant.Pkg())), e.Variant.Name(),
+		e.Def.Decl.TypeName, e.Def.Decl.Pos, want)
+}
+
+// variantStores holds the positions of the first conversions storing a
+// variant in its sum type by value and by pointer. A position is invalid if
+// there is no such conversion.
+type variantStores struct {
+	Value   token.Position
+	Pointer token.Position
+}
+
+// resolveReceiverForms records in each interface sum type where its variants
+// with value receivers are stored in it by value and by pointer. A variant is
+// stored by every conversion to the sum type, whether explicit as in
+// `T(&A{})`, or implicit in assignments, variable declarations, returns,
+// call arguments, composite literal elements and channel sends.
+func resolveReceiverForms(pkgs []*packages.Package, defs []sumTypeDef) {
+	for i := range defs {
+		if defs[i].Kind == kindInterface {
+			defs[i].Stores = map[types.Object]variantStores{}
+		}
+	}
+	for _, pkg := range pkgs {
+		conversions(pkg, func(to types.Type, expr ast.Expr) {
+			def := findDef(defs, to)
+			if def == nil || def.Kind != kindInterface {
+				return
+			}
+			ty := pkg.TypesInfo.TypeOf(expr)
+			ptr, isPointer := ty.(*types.Pointer)
+			if isPointer {
+				ty = ptr.Elem()
+			}
+			for _, v := range def.Variants {
+				if !def.ByValue[v] || isInterface(v.Type()) || !types.Identical(ty, v.Type()) {
+					continue
+				}
+				stores := def.Stores[v]
+				pos := pkg.Fset.Position(expr.Pos())
+				if isPointer && !stores.Pointer.IsValid() {
+					stores.Pointer = pos
+				} else if !isPointer && !stores.Value.IsValid() {
+					stores.Value = pos
+				}
+				def.Stores[v] = stores
+			}
+		})
+	}
+}
+
+// conversions calls fn with the target type and the value of every explicit
+// or implicit conversion in pkg. Assignments to the blank identifier, which
+// has no type, are skipped.
+func conversions(pkg *packages.Package, convert func(to types.Type, expr ast.Expr)) {
+	info := pkg.TypesInfo
+	fn := func(to types.Type, expr ast.Expr) {
+		if to != nil {
+			convert(to, expr)
+		}
+	}
+	for _, file := range pkg.Syntax {
+		var stack []ast.Node
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok == token.ASSIGN && len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						fn(info.TypeOf(lhs), n.Rhs[i])
+					}
+				}
+			case *ast.ValueSpec:
+				if n.Type != nil && len(n.Names) == len(n.Values) {
+					for _, value := range n.Values {
+						fn(info.TypeOf(n.Type), value)
+					}
+				}
+			case *ast.ReturnStmt:
+				sig := enclosingSignature(info, stack)
+				if sig != nil && sig.Results().Len() == len(n.Results) {
+					for i, result := range n.Results {
+						fn(sig.Results().At(i).Type(), result)
+					}
+				}
+			case *ast.SendStmt:
+				if ch, ok := typeOf(info, n.Chan).(*types.Chan); ok {
+					fn(ch.Elem(), n.Value)
+				}
+			case *ast.CallExpr:
+				if info.Types[n.Fun].IsType() {
+					if len(n.Args) == 1 {
+						fn(info.TypeOf(n.Fun), n.Args[0])
+					}
+					return true
+				}
+				sig, ok := typeOf(info, n.Fun).(*types.Signature)
+				if !ok {
+					return true
+				}
+				params := sig.Params()
+				for i, arg := range n.Args {
+					switch {
+					case sig.Variadic() && i >= params.Len()-1:
+						variadic := params.At(params.Len() - 1).Type()
+						if !n.Ellipsis.IsValid() {
+							variadic = variadic.(*types.Slice).Elem()
+						}
+						fn(variadic, arg)
+					case i < params.Len():
+						fn(params.At(i).Type(), arg)
+					}
+				}
+			case *ast.CompositeLit:
+				compositeConversions(info, n, fn)
+			}
+			return true
+		})
+	}
+}
+
+// typeOf returns the underlying type of expr, or nil if it has no type.
+func typeOf(info *types.Info, expr ast.Expr) types.Type {
+	if ty := info.TypeOf(expr); ty != nil {
+		return ty.Underlying()
+	}
+	return nil
+}
+
+// compositeConversions calls fn with the target type and the value of every
+// element of lit, and every key of lit if it is a map literal.
+func compositeConversions(info *types.Info, lit *ast.CompositeLit, fn func(to types.Type, expr ast.Expr)) {
+	ty := info.TypeOf(lit)
+	if ty == nil {
+		return
+	}
+	for i, elt := range lit.Elts {
+		kv, isKV := elt.(*ast.KeyValueExpr)
+		value := elt
+		if isKV {
+			value = kv.Value
+		}
+		switch ty := ty.Underlying().(type) {
+		case *types.Slice:
+			fn(ty.Elem(), value)
+		case *types.Array:
+			fn(ty.Elem(), value)
+		case *types.Map:
+			if isKV {
+				fn(ty.Key(), kv.Key)
+			}
+			fn(ty.Elem(), value)
+		case *types.Struct:
+			if isKV {
+				if field, ok := info.ObjectOf(kv.Key.(*ast.Ident)).(*types.Var); ok {
+					fn(field.Type(), value)
+				}
+			} else if i < ty.NumFields() {
+				fn(ty.Field(i).Type(), value)
+			}
+		}
+	}
+}
+
+// enclosingSignature returns the signature of the innermost function
+// declaration or literal in stack, a path of nodes from the root of a file.
+func enclosingSignature(info *types.Info, stack []ast.Node) *types.Signature {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncLit:
+			sig, _ := info.TypeOf(n).(*types.Signature)
+			return sig
+		case *ast.FuncDecl:
+			if fn, ok := info.Defs[n.Name].(*types.Func); ok {
+				return fn.Type().(*types.Signature)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// findAmbiguousReceivers returns an error for every concrete variant of def
+// with value receivers that is stored in def both by value and by pointer,
+// as recorded by resolveReceiverForms.
+func findAmbiguousReceivers(def *sumTypeDef) []error {
+	var errs []error
+	for _, v := range def.Variants {
+		stores := def.Stores[v]
+		if stores.Value.IsValid() && stores.Pointer.IsValid() {
+			errs = append(errs, ambiguousReceiverError{Def: *def, Variant: v, ValueUse: stores.Value, PointerUse: stores.Pointer})
+		}
+	}
+	return errs
+}
+
+// caseForm returns whether type switch cases over def must name the concrete
+// variant v by pointer. A variant with pointer receivers must always be
+// named by pointer. A variant with value receivers must be named in the form
+// it is stored in def with, and ok is false if it is stored in neither or
+// both forms, so that either form is accepted.
+func (def *sumTypeDef) caseForm(v types.Object) (pointer, ok bool) {
+	if !def.ByValue[v] {
+		return true, true
+	}
+	stores := def.Stores[v]
+	if stores.Value.IsValid() == stores.Pointer.IsValid() {
+		return false, false
+	}
+	return stores.Pointer.IsValid(), true
+}
+
+// strictCaseTypes returns the case types among tys that name a variant of def
+// in the form required by caseForm, or name no variant at all, so that with
+// Config.StrictReceivers a case of the wrong form does not cover a variant.
+func strictCaseTypes(def *sumTypeDef, tys []types.Type) []types.Type {
+	if def.Kind == kindUnion {
+		return tys
+	}
+	var strict []types.Type
+	for _, ty := range tys {
+		if v, pointer := def.caseVariant(ty); v != nil {
+			if want, ok := def.caseForm(v); ok && want != pointer {
+				continue
+			}
+		}
+		strict = append(strict, ty)
+	}
+	return strict
+}
+
+// caseVariant returns the concrete variant of def named by the case type ty,
+// if any, and whether ty names it by pointer.
+func (def *sumTypeDef) caseVariant(ty types.Type) (types.Object, bool) {
+	_, isPointer := ty.(*types.Pointer)
+	for _, v := range def.Variants {
+		if !isInterface(v.Type()) && types.Identical(indirect(ty), v.Type()) {
+			return v, isPointer
+		}
+	}
+	return nil, false
+}
+
+// checkCaseForms reports type switch cases over a sum type that name a
+// variant in another form than the one required by caseForm.
+func checkCaseForms(pkg *packages.Package, defs []sumTypeDef, swtch *ast.TypeSwitchStmt) []error {
+	def := findDef(defs, assertedType(pkg, findTypeAssertExpr(swtch)))
+	if def == nil || def.Kind != kindInterface {
+		return nil
+	}
+	exprs, _ := switchVariants(swtch.Body)
+	var errs []error
+	for _, expr := range exprs {
+		ty := pkg.TypesInfo.TypeOf(expr)
+		v, pointer := def.caseVariant(ty)
+		if v == nil {
+			continue
+		}
+		if want, ok := def.caseForm(v); ok && want != pointer {
+			errs = append(errs, receiverFormError{
+				Position: pkg.Fset.Position(expr.Pos()),
+				Def:      *def,
+				Variant:  v,
+				Case:     ty,
+				Pointer:  want,
+			})
+		}
+	}
+	return errs
+}
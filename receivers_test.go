@@ -0,0 +1,131 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const receiverVariants = `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+func use(T) {}
+`
+
+// TestStrictReceiversNotStored tests that either form of a value receiver
+// variant covers it when the variant is never stored in the sum type.
+func TestStrictReceiversNotStored(t *testing.T) {
+	code := receiverVariants + `
+func main() {
+	switch T(nil).(type) {
+	case *A, *B:
+	}
+	switch T(nil).(type) {
+	case A, *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{StrictReceivers: true})
+	assert.Equal(t, 0, len(errs))
+}
+
+// TestStrictReceiversStoredByPointer tests that a value receiver variant
+// stored by pointer, here by a return and a call argument, must be matched by
+// a pointer case.
+func TestStrictReceiversStoredByPointer(t *testing.T) {
+	code := receiverVariants + `
+func main() {
+	_ = func() T { return &A{} }
+	use(&A{})
+	switch T(nil).(type) {
+	case *A, *B:
+	}
+	switch T(nil).(type) {
+	case A, *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{StrictReceivers: true})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, []string{"A"}, missingNames(t, errs[0]))
+	ferr, ok := errs[1].(receiverFormError)
+	assert.True(t, ok, "error was not receiverFormError: %T", errs[1])
+	assert.Equal(t, "A", ferr.Variant.Name())
+	assert.True(t, ferr.Pointer)
+}
+
+// TestStrictReceiversStoredByValue tests that a value receiver variant stored
+// by value must be matched by a value case.
+func TestStrictReceiversStoredByValue(t *testing.T) {
+	code := receiverVariants + `
+func main() {
+	var t T = A{}
+	switch t.(type) {
+	case *A, *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{StrictReceivers: true})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, []string{"A"}, missingNames(t, errs[0]))
+	ferr, ok := errs[1].(receiverFormError)
+	assert.True(t, ok, "error was not receiverFormError: %T", errs[1])
+	assert.Equal(t, "A", ferr.Variant.Name())
+	assert.False(t, ferr.Pointer)
+}
+
+// TestStrictReceiversPointerReceiver tests that a value case of a pointer
+// receiver variant is reported and does not cover it.
+func TestStrictReceiversPointerReceiver(t *testing.T) {
+	code := receiverVariants + `
+func main() {
+	switch T(nil).(type) {
+	case A, B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{StrictReceivers: true})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, []string{"B"}, missingNames(t, errs[0]))
+	ferr, ok := errs[1].(receiverFormError)
+	assert.True(t, ok, "error was not receiverFormError: %T", errs[1])
+	assert.Equal(t, "B", ferr.Variant.Name())
+}
+
+// TestStrictReceiversAmbiguous tests that a variant stored in the sum type in
+// both forms is reported.
+func TestStrictReceiversAmbiguous(t *testing.T) {
+	code := receiverVariants + `
+func main() {
+	ts := []T{A{}, T(&A{})}
+	switch ts[0].(type) {
+	case A, *B:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{StrictReceivers: true})
+	assert.Equal(t, 1, len(errs))
+	aerr, ok := errs[0].(ambiguousReceiverError)
+	assert.True(t, ok, "error was not ambiguousReceiverError: %T", errs[0])
+	assert.Equal(t, "A", aerr.Variant.Name())
+}
@@ -0,0 +1,258 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// incompleteRegistryError is returned for each `sumtype:registry` declaration
+// that never constructs or mentions some variant of its sum type.
+type incompleteRegistryError struct {
+	Position token.Position
+	Def      sumTypeDef
+	Missing  []types.Object
+}
+
+func (e incompleteRegistryError) Pos() token.Position { return e.Position }
+func (e incompleteRegistryError) Error() string {
+	return fmt.Sprintf(
+		"%s: registry for sum type %q (from %s) is missing variants %s",
+		e.Pos(), e.Def.Decl.TypeName, e.Def.Decl.Pos, strings.Join(e.Names(), ", "))
+}
+
+// Names returns a sorted list of names corresponding to the missing variants.
+func (e incompleteRegistryError) Names() []string {
+	return sortedNames(e.Missing)
+}
+
+// missingRegistryTypeError corresponds to a `//sumtype:registry` directive
+// that does not name any sum type.
+type missingRegistryTypeError struct {
+	Position token.Position
+}
+
+func (e missingRegistryTypeError) Pos() token.Position { return e.Position }
+func (e missingRegistryTypeError) Error() string {
+	return fmt.Sprintf("%s: sumtype:registry directive is missing a sum type name", e.Pos())
+}
+
+// checkRegistries checks every declaration in pkg annotated with
+// `//sumtype:registry <sum type>...`. Such a declaration is either a function
+// or a package level variable, typically a map literal, that must register
+// every variant of the named sum types, as described by registeredTypes.
+func checkRegistries(pkg *packages.Package, defs []sumTypeDef) []error {
+	var errs []error
+	funcs := funcDecls(pkg)
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				errs = append(errs, checkRegistry(pkg, defs, funcs, d.Doc, d)...)
+			case *ast.GenDecl:
+				if d.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range d.Specs {
+					vspec := spec.(*ast.ValueSpec)
+					doc := vspec.Doc
+					if doc == nil && len(d.Specs) == 1 {
+						doc = d.Doc
+					}
+					errs = append(errs, checkRegistry(pkg, defs, funcs, doc, vspec)...)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// checkRegistry checks a single declaration if its documentation contains a
+// `//sumtype:registry` directive.
+func checkRegistry(
+	pkg *packages.Package,
+	defs []sumTypeDef,
+	funcs map[*types.Func]*ast.FuncDecl,
+	doc *ast.CommentGroup,
+	node ast.Node,
+) []error {
+	if doc == nil {
+		return nil
+	}
+	var errs []error
+	for _, line := range doc.List {
+		args, ok := directiveArgs(line.Text, "sumtype:registry")
+		if !ok {
+			continue
+		}
+		pos := pkg.Fset.Position(node.Pos())
+		if len(args) == 0 {
+			errs = append(errs, missingRegistryTypeError{Position: pos})
+			continue
+		}
+		covered := registeredTypes(pkg, funcs, node)
+		for _, name := range args {
+			def := lookupDef(defs, pkg, name)
+			if def == nil {
+				errs = append(errs, notFoundError{Decl: sumTypeDecl{Package: pkg, TypeName: name, Pos: pos}})
+				continue
+			}
+			if missing := def.missing(covered, false); len(missing) > 0 {
+				errs = append(errs, incompleteRegistryError{Position: pos, Def: *def, Missing: missing})
+			}
+		}
+	}
+	return errs
+}
+
+// registeredTypes returns the types of the values registered by node, namely
+// the arguments of calls and the keys and values of composite literals within
+// node. A registered function literal, or function of the same package,
+// registers the values it returns instead, so that `"a": newA` registers
+// whatever newA constructs.
+func registeredTypes(pkg *packages.Package, funcs map[*types.Func]*ast.FuncDecl, node ast.Node) []types.Type {
+	var tys []types.Type
+	seen := map[*ast.BlockStmt]bool{}
+	var register func(expr ast.Expr)
+	// returned registers the results of the return statements of body,
+	// excluding those of nested function literals.
+	returned := func(body *ast.BlockStmt) {
+		if seen[body] {
+			return
+		}
+		seen[body] = true
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				for _, result := range n.Results {
+					register(result)
+				}
+			}
+			return true
+		})
+	}
+	register = func(expr ast.Expr) {
+		expr = ast.Unparen(expr)
+		if tv, ok := pkg.TypesInfo.Types[expr]; ok {
+			tys = append(tys, tv.Type)
+		}
+		switch expr := expr.(type) {
+		case *ast.FuncLit:
+			returned(expr.Body)
+		case *ast.Ident:
+			if fn, ok := pkg.TypesInfo.Uses[expr].(*types.Func); ok && funcs[fn] != nil {
+				returned(funcs[fn].Body)
+			}
+		case *ast.CallExpr:
+			if ident, ok := ast.Unparen(expr.Fun).(*ast.Ident); ok {
+				if fn, ok := pkg.TypesInfo.Uses[ident].(*types.Func); ok && funcs[fn] != nil {
+					returned(funcs[fn].Body)
+				}
+			}
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			for _, arg := range n.Args {
+				register(arg)
+			}
+		case *ast.CompositeLit:
+			for _, elt := range n.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					register(kv.Key)
+					register(kv.Value)
+				} else {
+					register(elt)
+				}
+			}
+		}
+		return true
+	})
+	return tys
+}
+
+// inspectWithCallees calls fn for every expression within node and within
+// the bodies of functions of the same package that node refers to, visiting
+// each function at most once.
+func inspectWithCallees(
+	pkg *packages.Package,
+	funcs map[*types.Func]*ast.FuncDecl,
+	node ast.Node,
+	fn func(ast.Expr),
+) {
+	seen := map[ast.Node]bool{}
+	var visit func(n ast.Node)
+	visit = func(n ast.Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		ast.Inspect(n, func(n ast.Node) bool {
+			expr, ok := n.(ast.Expr)
+			if !ok {
+				return true
+			}
+			fn(expr)
+			if ident, ok := expr.(*ast.Ident); ok {
+				if obj, ok := pkg.TypesInfo.Uses[ident].(*types.Func); ok && funcs[obj] != nil {
+					visit(funcs[obj].Body)
+				}
+			}
+			return true
+		})
+	}
+	visit(node)
+}
+
+// funcDecls indexes the function declarations of pkg by their object.
+func funcDecls(pkg *packages.Package) map[*types.Func]*ast.FuncDecl {
+	funcs := map[*types.Func]*ast.FuncDecl{}
+	for _, file := range pkg.Syntax {
+		for _, d := range file.Decls {
+			if fn, ok := d.(*ast.FuncDecl); ok && fn.Body != nil {
+				if obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					funcs[obj] = fn
+				}
+			}
+		}
+	}
+	return funcs
+}
+
+// lookupDef returns the sum type definition referred to by name from pkg.
+// The name is either unqualified, referring to a sum type declared in pkg,
+// or qualified by a package name or import path, as in `ast.Expr` or
+// `go/ast.Expr`. If no such definition of a sum type of types exists, then
+// nil is returned.
+func lookupDef(defs []sumTypeDef, pkg *packages.Package, name string) *sumTypeDef {
+	qualifier, typeName := "", name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, typeName = name[:i], name[i+1:]
+	}
+	for i := range defs {
+		def := &defs[i]
+		// Sum types of values or fields have no types to register.
+		if def.Decl.TypeName != typeName || !def.hasTypes() {
+			continue
+		}
+		declPkg := def.Decl.Package
+		switch qualifier {
+		case "":
+			if declPkg.PkgPath == pkg.PkgPath {
+				return def
+			}
+		case declPkg.Name, declPkg.PkgPath:
+			return def
+		}
+	}
+	return nil
+}
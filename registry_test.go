@@ -0,0 +1,101 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const registryVariants = `
+package gochecksumtype
+
+//sumtype:decl
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+
+type B struct {}
+func (b *B) sealed() {}
+
+type C struct {}
+func (c *C) sealed() {}
+
+var registered []T
+
+func register(v T) { registered = append(registered, v) }
+
+func newA() T { return &A{} }
+`
+
+// TestRegistries tests that declarations annotated with `sumtype:registry`
+// report the variants they do not register with Config.CheckRegistries, and
+// that values merely created in a registry or its callees do not count.
+func TestRegistries(t *testing.T) {
+	code := registryVariants + `
+//sumtype:registry T
+var decoders = map[string]func() T{
+	"a": newA,
+	"b": func() T { return new(B) },
+}
+
+//sumtype:registry gochecksumtype.T
+var all = []T{&A{}, &B{}, &C{}}
+
+//sumtype:registry T
+func init() {
+	register(newA())
+	register(&B{})
+	register(&C{})
+}
+
+//sumtype:registry T
+func init() {
+	var c C
+	_ = c
+	register(&A{})
+	register(&B{})
+}
+
+func helper() { _ = &C{} }
+
+//sumtype:registry T
+func init() {
+	helper()
+	register(&A{})
+	register(&B{})
+}
+`
+	pkgs := setupPackages(t, code)
+
+	assert.Equal(t, 0, len(Run(pkgs, Config{})))
+	errs := Run(pkgs, Config{CheckRegistries: true})
+	assert.Equal(t, 3, len(errs))
+	var lines []int
+	for _, err := range errs {
+		ierr, ok := err.(incompleteRegistryError)
+		assert.True(t, ok, "error was not incompleteRegistryError: %T", err)
+		assert.Equal(t, []string{"C"}, ierr.Names())
+		lines = append(lines, ierr.Pos().Line)
+	}
+	assert.Equal(t, []int{23, 39, 49}, lines)
+}
+
+// TestRegistryInvalidDirective tests that registry directives naming an
+// undeclared sum type, or none at all, are reported.
+func TestRegistryInvalidDirective(t *testing.T) {
+	code := registryVariants + `
+//sumtype:registry U
+var all = []T{&A{}}
+
+//sumtype:registry
+var none = []T{&A{}}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{CheckRegistries: true})
+	assert.Equal(t, 2, len(errs))
+	assert.Equal(t, "U", errs[0].(notFoundError).Decl.TypeName)
+	_, ok := errs[1].(missingRegistryTypeError)
+	assert.True(t, ok, "error was not missingRegistryTypeError: %T", errs[1])
+}
@@ -0,0 +1,142 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// unmetRequirementError corresponds to a variant of a sum type that does not
+// implement an interface listed in the `requires` option of the sum type's
+// declaration.
+type unmetRequirementError struct {
+	Def      sumTypeDef
+	Variant  types.Object
+	Required string
+	Missing  []string
+}
+
+func (e unmetRequirementError) Pos() token.Position {
+	return e.Def.Decl.Package.Fset.Position(e.Variant.Pos())
+}
+
+func (e unmetRequirementError) Error() string {
+	return fmt.Sprintf(
+		"%s: variant %s of sum type %q (from %s) does not implement %s, missing %s",
+		e.Pos(), e.Variant.Name(), e.Def.Decl.TypeName, e.Def.Decl.Pos, e.Required, strings.Join(e.Missing, ", "))
+}
+
+// unknownRequirementError corresponds to an entry in the `requires` option of
+// a sum type declaration that does not name an interface.
+type unknownRequirementError struct {
+	Decl     sumTypeDecl
+	Required string
+}
+
+func (e unknownRequirementError) Pos() token.Position { return e.Decl.Pos }
+func (e unknownRequirementError) Error() string {
+	return fmt.Sprintf(
+		"%s: required interface %q of sum type %q not found, qualify it by the name of a package imported "+
+			"by the declaring file or by the import path of a dependency", e.Pos(), e.Required, e.Decl.TypeName)
+}
+
+// checkRequirements reports every concrete variant of def that does not
+// implement all of the interfaces listed in the `requires` option of its
+// declaration, e.g. `//sumtype:decl requires=fmt.Stringer,json.Marshaler`.
+//
+// Variants whose value type implements the sum type must implement the
+// required interfaces with their value type too, as they may be stored by
+// value. Sum types of sentinel values and oneof structs are not checked.
+func checkRequirements(def *sumTypeDef) []error {
+	option, ok := def.Decl.Options["requires"]
+	if !ok || !def.hasTypes() {
+		return nil
+	}
+	var errs []error
+	for _, name := range strings.Split(option, ",") {
+		if name == "" {
+			continue
+		}
+		iface := lookupInterface(def.Decl, name)
+		if iface == nil {
+			errs = append(errs, unknownRequirementError{Decl: def.Decl, Required: name})
+			continue
+		}
+		for _, v := range def.Variants {
+			if isInterface(v.Type()) {
+				continue
+			}
+			ty := v.Type()
+			if !def.ByValue[v] {
+				ty = types.NewPointer(ty)
+			}
+			if missing := missingMethods(ty, iface); len(missing) > 0 {
+				errs = append(errs, unmetRequirementError{Def: *def, Variant: v, Required: name, Missing: missing})
+			}
+		}
+	}
+	return errs
+}
+
+// lookupInterface resolves name as seen from the file declaring decl. The
+// name may be unqualified, e.g. "error", qualified by the name of a package
+// imported by that file, e.g. "fmt.Stringer", or qualified by the import path
+// of a dependency of the declaring package, direct or not, e.g.
+// "encoding/json.Marshaler". Nil is returned if name does not refer to an
+// interface.
+func lookupInterface(decl sumTypeDecl, name string) *types.Interface {
+	var obj types.Object
+	if i := strings.LastIndex(name, "."); i < 0 {
+		_, obj = decl.Package.Types.Scope().LookupParent(name, token.NoPos)
+	} else if imported := importedPackage(decl, name[:i]); imported != nil {
+		obj = imported.Scope().Lookup(name[i+1:])
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	iface, _ := tn.Type().Underlying().(*types.Interface)
+	return iface
+}
+
+// importedPackage returns the package that qualifier refers to in the file
+// declaring decl, either as the name of one of its imports or as the import
+// path of a dependency of the declaring package, or nil if there is none.
+func importedPackage(decl sumTypeDecl, qualifier string) *types.Package {
+	pkg := decl.Package
+	for _, file := range pkg.Syntax {
+		if pkg.Fset.File(file.Pos()).Name() != decl.Pos.Filename {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if pkgName := pkg.TypesInfo.PkgNameOf(spec); pkgName != nil && pkgName.Name() == qualifier {
+				return pkgName.Imported()
+			}
+		}
+	}
+	var found *types.Package
+	packages.Visit([]*packages.Package{pkg}, func(dep *packages.Package) bool {
+		if found == nil && dep.Types != nil && dep.PkgPath == qualifier {
+			found = dep.Types
+		}
+		return found == nil
+	}, nil)
+	return found
+}
+
+// missingMethods returns the names of the methods of iface that ty does not
+// have with an identical signature.
+func missingMethods(ty types.Type, iface *types.Interface) []string {
+	var missing []string
+	for i := range iface.NumMethods() {
+		m := iface.Method(i)
+		obj, _, _ := types.LookupFieldOrMethod(ty, false, m.Pkg(), m.Name())
+		if f, ok := obj.(*types.Func); !ok || !types.Identical(f.Type(), m.Type()) {
+			missing = append(missing, m.Name())
+		}
+	}
+	return missing
+}
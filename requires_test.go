@@ -0,0 +1,94 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestRequires tests that variants not implementing the interfaces required
+// by the declaration are reported along with their missing methods.
+func TestRequires(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "encoding/json"
+
+//sumtype:decl requires=fmt.Stringer,encoding/json.Marshaler,Named
+type T interface { sealed() }
+
+type Named interface { Name() string }
+
+type A struct {}
+func (a *A) sealed() {}
+func (a *A) String() string { return "A" }
+func (a *A) Name() string { return "A" }
+func (a *A) MarshalJSON() ([]byte, error) { return json.Marshal("A") }
+
+type B struct {}
+func (b B) sealed() {}
+func (b *B) String() string { return "B" }
+func (b B) Name() int { return 0 }
+func (b B) MarshalJSON() ([]byte, error) { return nil, nil }
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 2, len(errs))
+	for _, err := range errs {
+		rerr, ok := err.(unmetRequirementError)
+		assert.True(t, ok, "error was not unmetRequirementError: %T", err)
+		assert.Equal(t, "B", rerr.Variant.Name())
+	}
+	assert.Equal(t, "fmt.Stringer", errs[0].(unmetRequirementError).Required)
+	assert.Equal(t, []string{"String"}, errs[0].(unmetRequirementError).Missing)
+	assert.Equal(t, []string{"Name"}, errs[1].(unmetRequirementError).Missing)
+}
+
+// TestRequiresLookup tests that required interfaces are resolved through the
+// imports of the declaring file or by import path, and that those that cannot
+// be found are reported.
+func TestRequiresLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		imports  string
+		requires string
+		found    bool
+	}{
+		{name: "Import", imports: `import "math/rand"; var _ rand.Source`, requires: "rand.Source", found: true},
+		{name: "RenamedImport", imports: `import mrand "math/rand"; var _ mrand.Source`, requires: "mrand.Source", found: true},
+		{name: "ImportPath", imports: `import "encoding/json"; var _ json.Marshaler`, requires: "fmt.Stringer", found: true},
+		{name: "ImportPathNested", imports: `import "math/rand"; var _ rand.Source`, requires: "math/rand.Source", found: true},
+		{name: "OtherPackageOfSameName", imports: `import "crypto/rand"; var _ = rand.Reader`, requires: "rand.Source"},
+		{name: "NotADependency", requires: "fmt.Stringer"},
+		{name: "Unknown", requires: "nope.Stringer"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := `
+package gochecksumtype
+
+` + test.imports + `
+
+//sumtype:decl requires=` + test.requires + `
+type T interface { sealed() }
+
+type A struct {}
+func (a *A) sealed() {}
+func (a *A) String() string { return "A" }
+func (a *A) Int63() int64 { return 0 }
+func (a *A) Seed(int64) {}
+`
+			pkgs := setupPackages(t, code)
+
+			errs := Run(pkgs, Config{})
+			if test.found {
+				assert.Equal(t, 0, len(errs))
+				return
+			}
+			assert.Equal(t, 1, len(errs))
+			_, ok := errs[0].(unknownRequirementError)
+			assert.True(t, ok, "error was not unknownRequirementError: %T", errs[0])
+		})
+	}
+}
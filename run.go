@@ -1,26 +1,87 @@
 package gochecksumtype
 
-import "golang.org/x/tools/go/packages"
+import (
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
 
 // Run sumtype checking on the given packages.
 func Run(pkgs []*packages.Package, config Config) []error {
 	var errs []error
-
-	decls, err := findSumTypeDecls(pkgs)
-	if err != nil {
-		return []error{err}
+	if config.CheckDirectives {
+		for _, pkg := range pkgs {
+			errs = append(errs, checkDirectiveSpelling(pkg, config.DirectivePrefixes)...)
+		}
 	}
 
-	defs, defErrs := findSumTypeDefs(decls)
+	defs, defErrs := sumTypes(pkgs, config)
 	errs = append(errs, defErrs...)
 	if len(defs) == 0 {
 		return errs
 	}
+	errs = append(errs, resolveDiscriminators(pkgs, defs)...)
+	if config.StrictReceivers {
+		resolveReceiverForms(pkgs, defs)
+	}
 
 	for _, pkg := range pkgs {
 		if pkgErrs := check(pkg, defs, config); pkgErrs != nil {
 			errs = append(errs, pkgErrs...)
 		}
+		if config.CheckRegistries {
+			errs = append(errs, checkRegistries(pkg, defs)...)
+		}
+		if config.CheckGobRegistrations {
+			errs = append(errs, checkGobRegistrations(pkg, defs)...)
+		}
+		if config.CheckGenerated {
+			errs = append(errs, checkGenerated(pkg, config)...)
+		}
+		if config.CheckOneofLiterals {
+			errs = append(errs, checkOneofLiterals(pkg, defs)...)
+		}
+		errs = append(errs, checkMarkerBypasses(pkg, defs)...)
+		if config.CheckComparable {
+			errs = append(errs, checkComparisons(pkg, defs)...)
+		}
+		for i := range defs {
+			if defs[i].Decl.Package.PkgPath == pkg.PkgPath {
+				if config.CheckSealingLeaks {
+					errs = append(errs, findSealingLeaks(&defs[i])...)
+				}
+				errs = append(errs, checkRequirements(&defs[i])...)
+				if config.StrictReceivers {
+					errs = append(errs, findAmbiguousReceivers(&defs[i])...)
+				}
+			}
+		}
 	}
 	return errs
 }
+
+// sumTypes finds every sum type declared in the given packages, along with
+// those implied by config, and resolves each declaration to its definition.
+func sumTypes(pkgs []*packages.Package, config Config) ([]sumTypeDef, []error) {
+	decls, err := findSumTypeDecls(pkgs, config.DirectivePrefixes)
+	if err != nil {
+		return nil, []error{err}
+	}
+	decls = append(decls, findOneofStructDecls(pkgs)...)
+	if config.Protobuf {
+		decls = append(decls, findOneofDecls(pkgs)...)
+	}
+	external, errs := findExternalDecls(pkgs, config.ExternalSumTypes)
+	decls = append(decls, external...)
+	if config.Infer {
+		decls = append(decls, findInferredDecls(pkgs, decls, config.InferMarkerOnly)...)
+	}
+	var loaded []*types.Package
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.Types != nil {
+			loaded = append(loaded, pkg.Types)
+		}
+	})
+	defs, defErrs := findSumTypeDefs(decls, loaded)
+	return defs, append(errs, defErrs...)
+}
@@ -0,0 +1,142 @@
+package gochecksumtype
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// newSentinelDecl returns the declaration of a sum type whose variants are
+// the values of the variables with the given names, such as a set of
+// sentinel errors. The sum type is named by the `name` option, defaulting to
+// the names of its values joined by "|".
+func newSentinelDecl(pkg *packages.Package, pos token.Position, values []string, options map[string]string) sumTypeDecl {
+	name := options["name"]
+	if name == "" {
+		name = strings.Join(values, "|")
+	}
+	return sumTypeDecl{Package: pkg, TypeName: name, Pos: pos, Options: options, Kind: kindValues, Values: values}
+}
+
+// newSentinelDef returns the definition of a sum type of sentinel values,
+// whose variants are the package level variables named by decl. Such a
+// definition has no interface type. If a variable cannot be found, then nil
+// is returned.
+func newSentinelDef(pkg *types.Package, decl sumTypeDecl) *sumTypeDef {
+	def := &sumTypeDef{Decl: decl, Kind: kindValues, ByValue: map[types.Object]bool{}}
+	for _, name := range decl.Values {
+		v, ok := pkg.Scope().Lookup(name).(*types.Var)
+		if !ok {
+			return nil
+		}
+		debugf("  found sentinel value: %s.%s\n", pkg.Path(), name)
+		def.Variants = append(def.Variants, v)
+	}
+	return def
+}
+
+// sentinelDef returns the sum type of sentinel values that has the variable
+// referred to by expr as a variant, or nil if there is none.
+func sentinelDef(pkg *packages.Package, defs []sumTypeDef, expr ast.Expr) *sumTypeDef {
+	var ident *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		ident = expr
+	case *ast.SelectorExpr:
+		ident = expr.Sel
+	default:
+		return nil
+	}
+	v, ok := pkg.TypesInfo.Uses[ident].(*types.Var)
+	if !ok {
+		return nil
+	}
+	for i := range defs {
+		if def := &defs[i]; def.Kind == kindValues && slices.Contains(def.Variants, types.Object(v)) {
+			return def
+		}
+	}
+	return nil
+}
+
+// missingSentinels returns the variants of def not referred to by any of the
+// given expressions.
+func missingSentinels(pkg *packages.Package, def *sumTypeDef, exprs []ast.Expr) []types.Object {
+	covered := map[types.Object]bool{}
+	for _, expr := range exprs {
+		switch expr := ast.Unparen(expr).(type) {
+		case *ast.Ident:
+			covered[pkg.TypesInfo.Uses[expr]] = true
+		case *ast.SelectorExpr:
+			covered[pkg.TypesInfo.Uses[expr.Sel]] = true
+		}
+	}
+	var missing []types.Object
+	for _, v := range def.Variants {
+		if !covered[v] {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+// checkSentinelSwitch performs an exhaustiveness check on an expression
+// switch whose cases list values of a sum type of sentinel values, as in
+// `switch err { case ErrA, ErrB: }`. The sum type is determined by the first
+// case listing one of its values.
+func checkSentinelSwitch(
+	pkg *packages.Package,
+	defs []sumTypeDef,
+	swtch *ast.SwitchStmt,
+	config Config,
+) error {
+	if swtch.Tag == nil {
+		return nil
+	}
+	exprs, hasDefault := switchVariants(swtch.Body)
+	var def *sumTypeDef
+	for _, expr := range exprs {
+		if def = sentinelDef(pkg, defs, expr); def != nil {
+			break
+		}
+	}
+	if def == nil {
+		return nil
+	}
+	if config.DefaultSignifiesExhaustive && hasDefault && !defaultClauseAlwaysPanics(swtch.Body) {
+		return nil
+	}
+	if missing := missingSentinels(pkg, def, exprs); len(missing) > 0 {
+		return inexhaustiveError{
+			Position: pkg.Fset.Position(swtch.Pos()),
+			Def:      *def,
+			Missing:  missing,
+		}
+	}
+	return nil
+}
+
+// checkSentinelChain performs an exhaustiveness check on a chain of
+// errors.Is calls against values of a sum type of sentinel values. As with
+// errors.As, chains of a single check are ignored.
+func checkSentinelChain(pkg *packages.Package, defs []sumTypeDef, chain condChain, config Config) error {
+	if len(chain.targets) < 2 || (config.DefaultSignifiesExhaustive && chain.hasDefault) {
+		return nil
+	}
+	def := sentinelDef(pkg, defs, chain.targets[0])
+	if def == nil {
+		return nil
+	}
+	if missing := missingSentinels(pkg, def, chain.targets); len(missing) > 0 {
+		return inexhaustiveError{
+			Position: pkg.Fset.Position(chain.node.Pos()),
+			Def:      *def,
+			Missing:  missing,
+		}
+	}
+	return nil
+}
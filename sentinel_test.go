@@ -0,0 +1,127 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+const sentinelCode = `
+package gochecksumtype
+
+import "errors"
+
+//sumtype:decl name=Err
+var (
+	ErrA = errors.New("a")
+	ErrB = errors.New("b")
+	ErrC = errors.New("c")
+)
+
+func main() {
+	var err error
+	switch err {
+	case ErrA, ErrB:
+	}
+	switch err {
+	case ErrA, ErrB, ErrC:
+	}
+	switch err {
+	case ErrA:
+	default:
+	}
+	if errors.Is(err, ErrA) {
+	} else if errors.Is(err, ErrC) {
+	}
+	switch {
+	case errors.Is(err, ErrA):
+	case errors.Is(err, ErrB):
+	case errors.Is(err, ErrC):
+	}
+}
+`
+
+// TestSentinels tests that switches and errors.Is chains over sentinel values
+// declared by a var block are checked for missing values.
+func TestSentinels(t *testing.T) {
+	pkgs := setupPackages(t, sentinelCode)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 3, len(errs))
+	assert.Equal(t, []string{"ErrB"}, missingNames(t, errs[0]))
+	assert.Equal(t, []string{"ErrC"}, missingNames(t, errs[1]))
+	assert.Equal(t, []string{"ErrB", "ErrC"}, missingNames(t, errs[2]))
+	assert.Contains(t, errs[0].Error(), `sum type "Err"`)
+
+	errs = Run(pkgs, Config{DefaultSignifiesExhaustive: true})
+	assert.Equal(t, 2, len(errs))
+}
+
+// TestSentinelsDefaultName tests that sentinel sum types are named after
+// their values by default.
+func TestSentinelsDefaultName(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "errors"
+
+//sumtype:decl
+var ErrA, ErrB = errors.New("a"), errors.New("b")
+
+func main() {
+	var err error
+	switch err {
+	case ErrA:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 1, len(errs))
+	assert.Contains(t, errs[0].Error(), `sum type "ErrA|ErrB"`)
+}
+
+// TestSentinelsTypeBasedPasses tests that sum types of sentinel values do not
+// take part in the checks that compare variants by type, and that a registry
+// naming one is reported as naming no sum type of types.
+func TestSentinelsTypeBasedPasses(t *testing.T) {
+	code := `
+package gochecksumtype
+
+import "errors"
+
+//sumtype:decl name=GobErr gob
+var (
+	ErrA = errors.New("a")
+	ErrB = errors.New("b")
+)
+
+//sumtype:decl name=DiscriminatorErr discriminator=Error
+var (
+	ErrC = errors.New("c")
+	ErrD = errors.New("d")
+)
+
+//sumtype:decl name=RequiresErr requires=fmt.Stringer
+var (
+	ErrE = errors.New("e")
+	ErrF = errors.New("f")
+)
+
+//sumtype:registry GobErr
+var all = []error{ErrA, ErrB}
+
+func use(err error) {
+	switch err.(type) {
+	case nil:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{CheckRegistries: true, CheckGobRegistrations: true})
+	assert.Equal(t, 1, len(errs))
+	_, ok := errs[0].(notFoundError)
+	assert.True(t, ok, "error was not notFoundError: %T", errs[0])
+}
@@ -0,0 +1,122 @@
+package gochecksumtype
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// approximationError corresponds to a union constraint declared as a sum type
+// with a term of the form ~T, which any type with the underlying type T
+// satisfies, so that its type set is not closed.
+type approximationError struct {
+	Decl sumTypeDecl
+	Term *types.Term
+}
+
+func (e approximationError) Pos() token.Position { return e.Decl.Pos }
+func (e approximationError) Error() string {
+	return fmt.Sprintf(
+		"%s: union constraint '%s' is not closed, since every type with underlying type %s matches its term %s",
+		e.Decl.Location(), e.Decl.TypeName, e.Term.Type(), e.Term)
+}
+
+// newUnionDef returns the sum type definition of a union constraint such as
+// `interface { int64 | float64 | big.Int }`, whose variants are the types in
+// its type set. Terms of the form ~T are rejected, since any type with the
+// underlying type T satisfies them.
+//
+// Named terms are represented by their type names. Unnamed terms, such as
+// []byte, are represented by synthesized type names without a position.
+func newUnionDef(decl sumTypeDecl, iface *types.Interface) (*sumTypeDef, error) {
+	def := &sumTypeDef{
+		Decl:    decl,
+		Kind:    kindUnion,
+		Ty:      iface,
+		ByValue: map[types.Object]bool{},
+	}
+	terms, approx, ok := unionTerms(iface)
+	if approx != nil {
+		return nil, approximationError{Decl: decl, Term: approx}
+	}
+	if !ok {
+		return nil, unsealedError{decl}
+	}
+	for _, ty := range terms {
+		if !types.Satisfies(ty, iface) {
+			continue
+		}
+		var obj types.Object
+		if named, ok := ty.(*types.Named); ok {
+			obj = named.Obj()
+		} else if basic, ok := ty.(*types.Basic); ok {
+			obj = types.Universe.Lookup(basic.Name())
+		} else {
+			obj = types.NewTypeName(token.NoPos, nil, types.TypeString(ty, nil), ty)
+		}
+		debugf("  found union term: %s\n", obj.Name())
+		def.Variants = append(def.Variants, obj)
+		def.ByValue[obj] = true
+	}
+	return def, nil
+}
+
+// unionTerms returns the types listed by the unions embedded in iface,
+// expanding terms that are themselves union constraints. False is returned
+// if the type set of iface is not a finite set of types, either because it
+// contains a ~T term, which is then returned, or because it embeds no union.
+func unionTerms(iface *types.Interface) ([]types.Type, *types.Term, bool) {
+	var terms []types.Type
+	found := false
+	for i := range iface.NumEmbeddeds() {
+		var union []types.Type
+		switch embedded := iface.EmbeddedType(i).(type) {
+		case *types.Union:
+			for j := range embedded.Len() {
+				term := embedded.Term(j)
+				if term.Tilde() {
+					return nil, term, false
+				}
+				inner, ok := term.Type().Underlying().(*types.Interface)
+				if !ok {
+					union = append(union, term.Type())
+					continue
+				}
+				innerTerms, approx, ok := unionTerms(inner)
+				if !ok {
+					return nil, approx, false
+				}
+				union = append(union, innerTerms...)
+			}
+		default:
+			inner, ok := embedded.Underlying().(*types.Interface)
+			if !ok || inner.IsMethodSet() {
+				continue
+			}
+			innerTerms, approx, ok := unionTerms(inner)
+			if !ok {
+				return nil, approx, false
+			}
+			union = innerTerms
+		}
+		if found {
+			union = intersectTypes(terms, union)
+		}
+		terms, found = union, true
+	}
+	return terms, nil, found
+}
+
+// intersectTypes returns the types in a that are identical to a type in b.
+func intersectTypes(a, b []types.Type) []types.Type {
+	var both []types.Type
+	for _, x := range a {
+		for _, y := range b {
+			if types.Identical(x, y) {
+				both = append(both, x)
+				break
+			}
+		}
+	}
+	return both
+}
@@ -0,0 +1,72 @@
+package gochecksumtype
+
+import (
+	"testing"
+
+	"github.com/alecthomas/assert/v2"
+)
+
+// TestUnion tests that type switches over type parameters constrained by a
+// union are checked for missing terms, which must be matched exactly.
+func TestUnion(t *testing.T) {
+	code := `
+package gochecksumtype
+
+type Big struct { digits []byte }
+
+type Float interface { float32 | float64 }
+
+//sumtype:decl
+type Number interface { int64 | Float | Big | []byte }
+
+func Eval[N Number](n N) {
+	switch any(n).(type) {
+	case int64, float64:
+	case *Big:
+	}
+	switch any(n).(type) {
+	case int64, float32, float64, Big, []byte:
+	}
+}
+`
+	pkgs := setupPackages(t, code)
+
+	errs := Run(pkgs, Config{})
+	assert.Equal(t, 1, len(errs))
+	assert.Equal(t, []string{"Big", "[]byte", "float32"}, missingNames(t, errs[0]))
+}
+
+// TestUnionApproximation tests that unions with ~T terms, directly or in
+// embedded constraints, are rejected naming the term, since their type sets
+// are not closed.
+func TestUnionApproximation(t *testing.T) {
+	tests := []struct {
+		name string
+		decl string
+		term string
+	}{
+		{name: "Direct", decl: "type Integer interface { ~int | int64 }", term: "~int"},
+		{name: "Union", decl: "type Integer interface { Signed | uint }", term: "~int8"},
+		{name: "Embedded", decl: "type Integer interface { Signed }", term: "~int8"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code := `
+package gochecksumtype
+
+type Signed interface { ~int8 | int16 }
+
+//sumtype:decl
+` + test.decl + `
+`
+			pkgs := setupPackages(t, code)
+
+			errs := Run(pkgs, Config{})
+			assert.Equal(t, 1, len(errs))
+			aerr, ok := errs[0].(approximationError)
+			assert.True(t, ok, "error was not approximationError: %T", errs[0])
+			assert.Equal(t, test.term, aerr.Term.String())
+			assert.Contains(t, aerr.Error(), "underlying type")
+		})
+	}
+}